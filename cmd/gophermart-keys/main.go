@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rookgm/gophermart/internal/auth"
+	"log"
+	"os"
+)
+
+const usage = `usage: gophermart-keys <command> [-f keys file] [-alg HS256|EdDSA|RS256]
+
+commands:
+  generate  create new keys file with single key
+  rotate    add new signing key, previous keys are kept for verification
+  prune     remove old keys, keeping -keep newest keys
+  list      list keys
+
+Running server reloads keys file on SIGHUP.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd := os.Args[1]
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	path := fs.String("f", os.Getenv("AUTH_KEYS_FILE"), "token signing keys file")
+	keep := fs.Int("keep", 2, "number of newest keys to keep")
+	alg := fs.String("alg", auth.AlgHS256, "signing algorithm of new key: HS256, EdDSA or RS256")
+	fs.Parse(os.Args[2:])
+
+	if *path == "" {
+		log.Fatal("keys file is not set")
+	}
+
+	var err error
+	switch cmd {
+	case "generate":
+		err = generate(*path, *alg)
+	case "rotate":
+		err = rotate(*path, *alg)
+	case "prune":
+		err = prune(*path, *keep)
+	case "list":
+		err = list(*path)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate creates new keys file
+func generate(path string, alg string) error {
+	if _, err := os.Stat(path); err == nil {
+		return errors.New("keys file already exists, use rotate")
+	}
+
+	key, err := auth.GenerateKey(alg)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.NewKeySet(key).Save(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("generated key %s\n", key.ID)
+	return nil
+}
+
+// rotate adds new current key to keys file
+func rotate(path string, alg string) error {
+	keys, err := auth.LoadKeySet(path)
+	if err != nil {
+		return err
+	}
+
+	key, err := keys.Rotate(alg)
+	if err != nil {
+		return err
+	}
+
+	if err := keys.Save(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("rotated to key %s\n", key.ID)
+	return nil
+}
+
+// prune removes old keys from keys file
+func prune(path string, keep int) error {
+	keys, err := auth.LoadKeySet(path)
+	if err != nil {
+		return err
+	}
+
+	removed := keys.Prune(keep)
+
+	if err := keys.Save(path); err != nil {
+		return err
+	}
+
+	for _, key := range removed {
+		fmt.Printf("removed key %s\n", key.ID)
+	}
+	return nil
+}
+
+// list prints keys of keys file
+func list(path string) error {
+	keys, err := auth.LoadKeySet(path)
+	if err != nil {
+		return err
+	}
+
+	current := keys.Current().ID
+	for _, key := range keys.Keys() {
+		mark := " "
+		if key.ID == current {
+			mark = "*"
+		}
+		fmt.Printf("%s %s %-5s %s\n", mark, key.ID, key.Algorithm, key.CreatedAt.Format("2006-01-02T15:04:05Z07:00"))
+	}
+	return nil
+}
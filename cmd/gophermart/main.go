@@ -3,20 +3,33 @@ package main
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/rookgm/gophermart/config"
+	"github.com/rookgm/gophermart/internal/accrual"
 	"github.com/rookgm/gophermart/internal/auth"
 	handler "github.com/rookgm/gophermart/internal/handler/http"
 	"github.com/rookgm/gophermart/internal/middleware"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/notify"
+	"github.com/rookgm/gophermart/internal/ordernum"
+	"github.com/rookgm/gophermart/internal/password"
+	"github.com/rookgm/gophermart/internal/policy"
 	"github.com/rookgm/gophermart/internal/repository"
 	"github.com/rookgm/gophermart/internal/repository/postgres"
 	"github.com/rookgm/gophermart/internal/service"
+	"github.com/rookgm/gophermart/internal/worker"
 	"go.uber.org/zap"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
-const authTokenKey = "f53ac685bbceebd75043e6be2e06ee07"
+// expiryInterval is interval of expiring points and pruning revoked tokens
+const expiryInterval = time.Hour
 
 // newLogger creates logger with log level
 func newLogger(level string) (*zap.Logger, error) {
@@ -31,6 +44,49 @@ func newLogger(level string) (*zap.Logger, error) {
 	return loggerCfg.Build()
 }
 
+// errAuthKeyNotConfigured is returned if neither signing keys file nor signing key is set
+var errAuthKeyNotConfigured = errors.New("token signing key is not configured, set AUTH_KEYS_FILE or AUTH_KEY")
+
+// loadAuthKeys loads token signing keys from keys file or key given in environment
+func loadAuthKeys(cfg *config.Config) (*auth.KeySet, error) {
+	if cfg.AuthKeysFile != "" {
+		return auth.LoadKeySet(cfg.AuthKeysFile)
+	}
+
+	if cfg.AuthKey != "" {
+		secret, err := hex.DecodeString(cfg.AuthKey)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewKeySet(auth.Key{ID: "default", Algorithm: auth.AlgHS256, Secret: secret}), nil
+	}
+
+	// random key would silently log out all users on every restart
+	return nil, errAuthKeyNotConfigured
+}
+
+// reloadAuthKeys reloads token signing keys from keys file on SIGHUP
+func reloadAuthKeys(ctx context.Context, path string, token *auth.AuthToken, logger *zap.Logger) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			keys, err := auth.LoadKeySet(path)
+			if err != nil {
+				logger.Error("Error reloading signing keys", zap.Error(err))
+				continue
+			}
+			token.SetKeys(keys)
+			logger.Info("Signing keys reloaded", zap.String("kid", keys.Current().ID))
+		}
+	}
+}
+
 func main() {
 
 	// create new config
@@ -63,39 +119,151 @@ func main() {
 		logger.Fatal("Error migrating database", zap.Error(err))
 	}
 
-	tokenKey, err := hex.DecodeString(authTokenKey)
+	tokenKeys, err := loadAuthKeys(cfg)
 	if err != nil {
-		logger.Fatal("Error extracting token key", zap.Error(err))
+		logger.Fatal("Error loading signing keys", zap.Error(err))
+	}
+	token := auth.NewAuthToken(tokenKeys, cfg.AccessTokenTTL)
+
+	if cfg.AuthKeysFile != "" {
+		go reloadAuthKeys(ctx, cfg.AuthKeysFile, token, logger)
 	}
-	token := auth.NewAuthToken(tokenKey)
+	jwksHandler := handler.NewJWKSHandler(token)
 
 	// dependency injection
-	// user
-	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo, token)
-	userHandler := handler.NewUserHandler(userService)
+	uow := unitOfWork{uow: repository.NewUnitOfWork(db)}
+
+	// tenant
+	tenantRepo := repository.NewTenantRepository(db)
+	tenantService := service.NewTenantService(tenantRepo)
+
+	orderNumberValidator := ordernum.NewValidator(tenantService)
+
+	// audit
+	auditRepo := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepo, logger)
+
+	// bonus
+	ledgerRepo := repository.NewLedgerRepository(db)
+	bonusService := service.NewBonusService(ledgerRepo, cfg.PointsTTL)
+	bonusHandler := handler.NewBonusHandler(bonusService)
 
 	// auth
-	authService := service.NewAuthService(userRepo, token)
+	passwordHasher, err := password.NewHasher(cfg.PasswordHash, cfg.BcryptCost, password.DefaultArgon2Params)
+	if err != nil {
+		logger.Fatal("Error initializing password hasher", zap.Error(err))
+	}
+	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	var attemptRepo service.LoginAttemptRepository
+	switch cfg.LoginThrottleStore {
+	case "postgres":
+		attemptRepo = loginAttemptRepo
+	case "memory":
+		attemptRepo = repository.NewMemoryLoginAttemptRepository()
+	default:
+		logger.Fatal("Unknown login throttle store", zap.String("store", cfg.LoginThrottleStore))
+	}
+	throttleService := service.NewThrottleService(attemptRepo, loginAttemptRepo, cfg.LoginMaxAttempts, cfg.LoginMaxAttemptsIP, cfg.LoginLockout)
+	authService, err := service.NewAuthService(userRepo, tokenRepo, token, uow, passwordHasher, throttleService, auditService, cfg.RefreshTokenTTL)
+	if err != nil {
+		logger.Fatal("Error initializing auth service", zap.Error(err))
+	}
 	authHandler := handler.NewAuthHandler(authService)
 
+	// user
+	credentialPolicy := policy.New(cfg.PasswordMinLength, passwordHasher.MaxBytes(), cfg.PasswordMinClasses)
+	userService := service.NewUserService(userRepo, token, uow, bonusService, passwordHasher, credentialPolicy, auditService)
+	userHandler := handler.NewUserHandler(userService, authService)
+
+	// password
+	// reset tokens are secrets, so they are never written to log unless asked explicitly
+	var resetNotifier service.ResetNotifier
+	switch {
+	case cfg.PasswordResetFile != "":
+		resetNotifier = notify.NewFileNotifier(cfg.PasswordResetFile)
+	case cfg.PasswordResetLog:
+		logger.Warn("Password reset tokens are written to log")
+		resetNotifier = notify.NewLogNotifier(logger)
+	default:
+		logger.Info("Password reset is disabled, no notifier is configured")
+	}
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	passwordService := service.NewPasswordService(userRepo, passwordResetRepo, uow, passwordHasher, credentialPolicy, resetNotifier, auditService, cfg.PasswordResetTTL)
+	passwordHandler := handler.NewPasswordHandler(passwordService, authService)
+
 	// order
 	orderRepo := repository.NewOrderRepository(db)
-	orderService := service.NewOrderService(orderRepo)
+	orderService := service.NewOrderService(orderRepo, orderNumberValidator, auditService)
 	orderHandler := handler.NewOrderHandler(orderService)
 
+	// balance
+	withdrawalRepo := repository.NewWithdrawalRepository(db)
+	balanceService := service.NewBalanceService(ledgerRepo, withdrawalRepo, uow, orderNumberValidator, auditService, cfg.PointsTTL, cfg.PointsExpiringSoon)
+	balanceHandler := handler.NewBalanceHandler(balanceService)
+
+	// back-office
+	adminHandler := handler.NewAdminHandler(userService, orderService, balanceService, auditService)
+
+	// accrual
+	accrualService := service.NewAccrualService(uow, bonusService, auditService, cfg.PointsTTL)
+	// tenants without own accrual system use configured one
+	accrualRouter := accrual.NewRouter(cfg.AccrualSystemAddr)
+	accrualWorker := worker.NewAccrualWorker(orderRepo, accrualService, tenantService, accrualRouter, logger, cfg.AccrualWorkers, cfg.AccrualPollInterval)
+
+	// start polling accrual system
+	go accrualWorker.Run(ctx)
+
+	// points expiry and revoked tokens pruning. Points credited while TTL was set
+	// still expire after TTL is turned off, so worker runs regardless of it.
+	expiryService := service.NewExpiryService(ledgerRepo, tokenRepo, uow)
+	expiryWorker := worker.NewExpiryWorker(expiryService, logger, expiryInterval)
+
+	go expiryWorker.Run(ctx)
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.Logging(logger))
+	router.Use(middleware.RequestInfo)
+	router.Use(middleware.Tenant(tenantService))
 
+	router.Get("/.well-known/jwks.json", jwksHandler.GetKeys())
 	router.Post("/api/user/register", userHandler.RegisterUser())
 	router.Post("/api/user/login", authHandler.LoginUser())
+	router.Post("/api/user/token/refresh", authHandler.RefreshToken())
+	router.Post("/api/user/password/reset/request", passwordHandler.RequestReset())
+	router.Post("/api/user/password/reset", passwordHandler.ResetPassword())
 
 	// routes that require authentication
 	router.Group(func(group chi.Router) {
-		group.Use(middleware.Auth(token))
+		group.Use(middleware.Auth(token, authService))
+		group.Post("/api/user/logout", authHandler.Logout())
+		group.Put("/api/user/password", passwordHandler.ChangePassword())
 		group.Post("/api/user/orders", orderHandler.UploadOrder())
+		group.Post("/api/user/orders/batch", orderHandler.UploadOrders())
 		group.Get("/api/user/orders", orderHandler.ListOrders())
+		group.Get("/api/user/balance", balanceHandler.GetBalance())
+		group.Post("/api/user/balance/withdraw", balanceHandler.Withdraw())
+		group.Get("/api/user/withdrawals", balanceHandler.ListWithdrawals())
+		group.Get("/api/user/bonuses", bonusHandler.ListBonuses())
+	})
+
+	// back-office routes, support staff may only read
+	router.Route("/api/admin", func(admin chi.Router) {
+		admin.Use(middleware.Auth(token, authService))
+		admin.Use(middleware.RequireRole(models.RoleSupport, models.RoleAdmin))
+		admin.Get("/users", adminHandler.SearchUsers())
+		admin.Get("/users/{userID}/balance", adminHandler.GetUserBalance())
+		admin.Get("/users/{userID}/orders", adminHandler.ListUserOrders())
+		admin.Get("/users/{userID}/withdrawals", adminHandler.ListUserWithdrawals())
+		admin.Get("/audit", adminHandler.ListAuditEvents())
+
+		admin.Group(func(write chi.Router) {
+			write.Use(middleware.RequireRole(models.RoleAdmin))
+			write.Post("/users/{userID}/adjustments", adminHandler.AdjustBalance())
+			write.Post("/orders/{number}/requeue", adminHandler.RequeueOrder())
+		})
 	})
 
 	logger.Info("Running server", zap.String("addr", cfg.GMartServerAddr))
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/repository"
+	"github.com/rookgm/gophermart/internal/service"
+)
+
+// unitOfWork adapts repository unit of work to service UnitOfWork interface
+type unitOfWork struct {
+	uow *repository.UnitOfWork
+}
+
+// WithTx runs fn with repositories bound to single transaction
+func (u unitOfWork) WithTx(ctx context.Context, fn func(repos service.TxRepositories) error) error {
+	return u.uow.WithTx(ctx, func(repos *repository.TxRepositories) error {
+		return fn(txRepositories{repos: repos})
+	})
+}
+
+// txRepositories adapts repositories bound to transaction to service TxRepositories interface
+type txRepositories struct {
+	repos *repository.TxRepositories
+}
+
+// Users returns user repository bound to transaction
+func (r txRepositories) Users() service.UserRepository {
+	return r.repos.Users()
+}
+
+// Orders returns order repository bound to transaction
+func (r txRepositories) Orders() service.OrderRepository {
+	return r.repos.Orders()
+}
+
+// Withdrawals returns withdrawal repository bound to transaction
+func (r txRepositories) Withdrawals() service.WithdrawalRepository {
+	return r.repos.Withdrawals()
+}
+
+// Ledger returns ledger repository bound to transaction
+func (r txRepositories) Ledger() service.LedgerRepository {
+	return r.repos.Ledger()
+}
+
+// BonusRules returns bonus rule repository bound to transaction
+func (r txRepositories) BonusRules() service.BonusRuleRepository {
+	return r.repos.BonusRules()
+}
+
+// Tokens returns token repository bound to transaction
+func (r txRepositories) Tokens() service.TokenRepository {
+	return r.repos.Tokens()
+}
+
+// PasswordResets returns password reset repository bound to transaction
+func (r txRepositories) PasswordResets() service.PasswordResetRepository {
+	return r.repos.PasswordResets()
+}
@@ -3,26 +3,63 @@ package config
 import (
 	"flag"
 	"os"
+	"strconv"
 	"sync"
+	"time"
 )
 
 const (
-	defaultGMartServerAddress = ":8080"
-	defaultGMartDatabaseDSN   = ""
-	defaultAccrualSystemAddr  = ":8181"
-	defaultLogLevel           = "debug"
+	defaultGMartServerAddress  = ":8080"
+	defaultGMartDatabaseDSN    = ""
+	defaultAccrualSystemAddr   = ":8181"
+	defaultLogLevel            = "debug"
+	defaultAccrualWorkers      = 4
+	defaultAccrualPollInterval = 1 * time.Second
+	defaultPointsTTL           = 0
+	defaultPointsExpiringSoon  = 30 * 24 * time.Hour
+	defaultAccessTokenTTL      = 15 * time.Minute
+	defaultRefreshTokenTTL     = 30 * 24 * time.Hour
+	defaultLoginMaxAttempts    = 5
+	defaultLoginMaxAttemptsIP  = 50
+	defaultLoginLockout        = 15 * time.Minute
+	defaultLoginThrottleStore  = "postgres"
+	defaultPasswordMinLength   = 8
+	defaultPasswordMinClasses  = 2
+	defaultPasswordResetTTL    = time.Hour
+	defaultPasswordHash        = "bcrypt"
+	defaultBcryptCost          = 10
 )
 
 type Config struct {
-	GMartServerAddr   string
-	GMartDatabaseDSN  string
-	AccrualSystemAddr string
-	LogLevel          string
+	GMartServerAddr     string
+	GMartDatabaseDSN    string
+	AccrualSystemAddr   string
+	LogLevel            string
+	AccrualWorkers      int
+	AccrualPollInterval time.Duration
+	PointsTTL           time.Duration
+	PointsExpiringSoon  time.Duration
+	AccessTokenTTL      time.Duration
+	RefreshTokenTTL     time.Duration
+	AuthKeysFile        string
+	AuthKey             string
+	LoginMaxAttempts    int
+	LoginMaxAttemptsIP  int
+	LoginLockout        time.Duration
+	LoginThrottleStore  string
+	PasswordMinLength   int
+	PasswordMinClasses  int
+	PasswordResetTTL    time.Duration
+	PasswordResetFile   string
+	PasswordResetLog    bool
+	PasswordHash        string
+	BcryptCost          int
 }
 
 var (
 	once      sync.Once
 	singleton *Config
+	configErr error
 )
 
 // New returns new Config. It parses command line and environment variables only once.
@@ -35,6 +72,24 @@ func New() (*Config, error) {
 		flag.StringVar(&cfg.GMartDatabaseDSN, "d", defaultGMartDatabaseDSN, "gopher mart database DSN")
 		flag.StringVar(&cfg.AccrualSystemAddr, "r", defaultAccrualSystemAddr, "accrual system address")
 		flag.StringVar(&cfg.LogLevel, "l", defaultLogLevel, "log level")
+		flag.IntVar(&cfg.AccrualWorkers, "w", defaultAccrualWorkers, "number of accrual workers")
+		flag.DurationVar(&cfg.AccrualPollInterval, "p", defaultAccrualPollInterval, "accrual poll interval")
+		flag.DurationVar(&cfg.PointsTTL, "e", defaultPointsTTL, "points expiration period, 0 - never expire")
+		flag.DurationVar(&cfg.PointsExpiringSoon, "s", defaultPointsExpiringSoon, "period of points reported as expiring soon")
+		flag.DurationVar(&cfg.AccessTokenTTL, "t", defaultAccessTokenTTL, "access token lifetime")
+		flag.DurationVar(&cfg.RefreshTokenTTL, "f", defaultRefreshTokenTTL, "refresh token lifetime")
+		flag.StringVar(&cfg.AuthKeysFile, "k", "", "token signing keys file")
+		flag.IntVar(&cfg.LoginMaxAttempts, "m", defaultLoginMaxAttempts, "failed login attempts before login is locked out")
+		flag.IntVar(&cfg.LoginMaxAttemptsIP, "i", defaultLoginMaxAttemptsIP, "failed login attempts before client IP is locked out")
+		flag.DurationVar(&cfg.LoginLockout, "o", defaultLoginLockout, "login lockout period")
+		flag.StringVar(&cfg.LoginThrottleStore, "g", defaultLoginThrottleStore, "failed login attempts store: postgres or memory")
+		flag.IntVar(&cfg.PasswordMinLength, "n", defaultPasswordMinLength, "minimum password length")
+		flag.IntVar(&cfg.PasswordMinClasses, "c", defaultPasswordMinClasses, "minimum number of character classes in password")
+		flag.DurationVar(&cfg.PasswordResetTTL, "x", defaultPasswordResetTTL, "password reset token lifetime")
+		flag.StringVar(&cfg.PasswordResetFile, "j", "", "file password reset tokens are written to")
+		flag.BoolVar(&cfg.PasswordResetLog, "u", false, "write password reset tokens to log, for local use only")
+		flag.StringVar(&cfg.PasswordHash, "y", defaultPasswordHash, "password hashing algorithm: bcrypt or argon2id")
+		flag.IntVar(&cfg.BcryptCost, "z", defaultBcryptCost, "bcrypt cost")
 
 		flag.Parse()
 
@@ -51,9 +106,135 @@ func New() (*Config, error) {
 		if logLevelEnv := os.Getenv("LOG_LEVEL"); logLevelEnv != "" {
 			cfg.LogLevel = logLevelEnv
 		}
+		if accrualWorkersEnv := os.Getenv("ACCRUAL_WORKERS"); accrualWorkersEnv != "" {
+			workers, err := strconv.Atoi(accrualWorkersEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.AccrualWorkers = workers
+		}
+		if accrualPollIntervalEnv := os.Getenv("ACCRUAL_POLL_INTERVAL"); accrualPollIntervalEnv != "" {
+			interval, err := time.ParseDuration(accrualPollIntervalEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.AccrualPollInterval = interval
+		}
+		if pointsTTLEnv := os.Getenv("POINTS_TTL"); pointsTTLEnv != "" {
+			ttl, err := time.ParseDuration(pointsTTLEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.PointsTTL = ttl
+		}
+		if pointsExpiringSoonEnv := os.Getenv("POINTS_EXPIRING_SOON"); pointsExpiringSoonEnv != "" {
+			period, err := time.ParseDuration(pointsExpiringSoonEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.PointsExpiringSoon = period
+		}
+		if accessTokenTTLEnv := os.Getenv("ACCESS_TOKEN_TTL"); accessTokenTTLEnv != "" {
+			ttl, err := time.ParseDuration(accessTokenTTLEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.AccessTokenTTL = ttl
+		}
+		if refreshTokenTTLEnv := os.Getenv("REFRESH_TOKEN_TTL"); refreshTokenTTLEnv != "" {
+			ttl, err := time.ParseDuration(refreshTokenTTLEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.RefreshTokenTTL = ttl
+		}
+		if authKeysFileEnv := os.Getenv("AUTH_KEYS_FILE"); authKeysFileEnv != "" {
+			cfg.AuthKeysFile = authKeysFileEnv
+		}
+		if loginMaxAttemptsEnv := os.Getenv("LOGIN_MAX_ATTEMPTS"); loginMaxAttemptsEnv != "" {
+			attempts, err := strconv.Atoi(loginMaxAttemptsEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.LoginMaxAttempts = attempts
+		}
+		if loginMaxAttemptsIPEnv := os.Getenv("LOGIN_MAX_ATTEMPTS_IP"); loginMaxAttemptsIPEnv != "" {
+			attempts, err := strconv.Atoi(loginMaxAttemptsIPEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.LoginMaxAttemptsIP = attempts
+		}
+		if loginLockoutEnv := os.Getenv("LOGIN_LOCKOUT"); loginLockoutEnv != "" {
+			lockout, err := time.ParseDuration(loginLockoutEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.LoginLockout = lockout
+		}
+		if loginThrottleStoreEnv := os.Getenv("LOGIN_THROTTLE_STORE"); loginThrottleStoreEnv != "" {
+			cfg.LoginThrottleStore = loginThrottleStoreEnv
+		}
+		if passwordMinLengthEnv := os.Getenv("PASSWORD_MIN_LENGTH"); passwordMinLengthEnv != "" {
+			length, err := strconv.Atoi(passwordMinLengthEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.PasswordMinLength = length
+		}
+		if passwordMinClassesEnv := os.Getenv("PASSWORD_MIN_CLASSES"); passwordMinClassesEnv != "" {
+			classes, err := strconv.Atoi(passwordMinClassesEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.PasswordMinClasses = classes
+		}
+		if passwordResetTTLEnv := os.Getenv("PASSWORD_RESET_TTL"); passwordResetTTLEnv != "" {
+			ttl, err := time.ParseDuration(passwordResetTTLEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.PasswordResetTTL = ttl
+		}
+		if passwordResetFileEnv := os.Getenv("PASSWORD_RESET_FILE"); passwordResetFileEnv != "" {
+			cfg.PasswordResetFile = passwordResetFileEnv
+		}
+		if passwordResetLogEnv := os.Getenv("PASSWORD_RESET_LOG"); passwordResetLogEnv != "" {
+			resetLog, err := strconv.ParseBool(passwordResetLogEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.PasswordResetLog = resetLog
+		}
+		if passwordHashEnv := os.Getenv("PASSWORD_HASH"); passwordHashEnv != "" {
+			cfg.PasswordHash = passwordHashEnv
+		}
+		if bcryptCostEnv := os.Getenv("BCRYPT_COST"); bcryptCostEnv != "" {
+			cost, err := strconv.Atoi(bcryptCostEnv)
+			if err != nil {
+				configErr = err
+				return
+			}
+			cfg.BcryptCost = cost
+		}
+		// signing key is secret, so it is not accepted from command line
+		cfg.AuthKey = os.Getenv("AUTH_KEY")
 
 		singleton = &cfg
 	})
 
-	return singleton, nil
+	return singleton, configErr
 }
@@ -0,0 +1,147 @@
+package accrual
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rookgm/gophermart/internal/models"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	requestTimeout    = 5 * time.Second
+	defaultRetryAfter = 60 * time.Second
+)
+
+var (
+	ErrOrderNotRegistered = errors.New("order is not registered in accrual system")
+	ErrUnexpectedStatus   = errors.New("unexpected accrual system response status")
+	ErrTooManyRequests    = errors.New("too many requests to accrual system")
+)
+
+// rateLimitRe matches accrual system rate limit message "No more than N requests per minute allowed"
+var rateLimitRe = regexp.MustCompile(`(?i)no more than (\d+) requests per minute`)
+
+// orderResponse is accrual system order response
+type orderResponse struct {
+	Order   string   `json:"order"`
+	Status  string   `json:"status"`
+	Accrual *float64 `json:"accrual,omitempty"`
+}
+
+// Client is accrual system HTTP client
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	limiter    *Limiter
+}
+
+// NewClient creates new accrual system Client instance
+func NewClient(addr string) *Client {
+	return &Client{
+		baseURL:    baseURL(addr),
+		httpClient: &http.Client{Timeout: requestTimeout},
+		limiter:    NewLimiter(),
+	}
+}
+
+// GetOrderAccrual returns order accrual calculated by accrual system.
+// Requests are throttled by the client limiter and retried while accrual system responds with 429.
+func (c *Client) GetOrderAccrual(ctx context.Context, number string) (*models.Accrual, error) {
+	for {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		acc, err := c.getOrderAccrual(ctx, number)
+		if errors.Is(err, ErrTooManyRequests) {
+			continue
+		}
+
+		return acc, err
+	}
+}
+
+// getOrderAccrual performs single order accrual request
+func (c *Client) getOrderAccrual(ctx context.Context, number string) (*models.Accrual, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/orders/"+url.PathEscape(number), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var orderResp orderResponse
+		if err := json.NewDecoder(resp.Body).Decode(&orderResp); err != nil {
+			return nil, err
+		}
+		return &models.Accrual{
+			Order:   orderResp.Order,
+			Status:  orderResp.Status,
+			Accrual: orderResp.Accrual,
+		}, nil
+	case http.StatusNoContent:
+		return nil, ErrOrderNotRegistered
+	case http.StatusTooManyRequests:
+		c.throttle(resp)
+		return nil, ErrTooManyRequests
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+}
+
+// throttle tunes request rate and pauses requests according to 429 response
+func (c *Client) throttle(resp *http.Response) {
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		if m := rateLimitRe.FindSubmatch(body); m != nil {
+			if n, err := strconv.Atoi(string(m[1])); err == nil {
+				c.limiter.SetRate(n, time.Minute)
+			}
+		}
+	}
+
+	c.limiter.Pause(retryAfter(resp.Header.Get("Retry-After")))
+}
+
+// retryAfter parses Retry-After header value given in seconds or as HTTP date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return defaultRetryAfter
+	}
+
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+
+	return defaultRetryAfter
+}
+
+// baseURL makes accrual system base URL from address
+func baseURL(addr string) string {
+	addr = strings.TrimRight(addr, "/")
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return addr
+}
@@ -0,0 +1,92 @@
+package accrual
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is rate limiter shared by all requests to accrual system.
+// It spaces requests out by interval and pauses all of them while accrual system asks to wait.
+type Limiter struct {
+	mu          sync.Mutex
+	interval    time.Duration
+	next        time.Time
+	pausedUntil time.Time
+}
+
+// NewLimiter creates new Limiter instance without rate limit
+func NewLimiter() *Limiter {
+	return &Limiter{}
+}
+
+// Wait blocks until request is allowed or context is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+
+		at := l.next
+		if at.Before(now) {
+			at = now
+		}
+		if at.Before(l.pausedUntil) {
+			at = l.pausedUntil
+		}
+		l.next = at.Add(l.interval)
+		l.mu.Unlock()
+
+		if err := sleep(ctx, at.Sub(now)); err != nil {
+			return err
+		}
+
+		// request may have been paused while waiting
+		l.mu.Lock()
+		paused := time.Now().Before(l.pausedUntil)
+		l.mu.Unlock()
+
+		if !paused {
+			return nil
+		}
+	}
+}
+
+// Pause pauses all requests for duration
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// SetRate limits requests to n per period
+func (l *Limiter) SetRate(n int, per time.Duration) {
+	if n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.interval = per / time.Duration(n)
+}
+
+// sleep pauses current goroutine for duration or until context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
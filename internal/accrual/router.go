@@ -0,0 +1,65 @@
+package accrual
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"sync"
+)
+
+// Router routes requests to accrual systems of tenants. Tenants without own accrual
+// system address use default accrual system. Each accrual system has its own client,
+// so rate limit of one system does not slow down requests to others.
+type Router struct {
+	defaultAddr string
+	mu          sync.Mutex
+	addrs       map[uint64]string
+	clients     map[string]*Client
+}
+
+// NewRouter creates new Router instance
+func NewRouter(defaultAddr string) *Router {
+	return &Router{
+		defaultAddr: defaultAddr,
+		addrs:       map[uint64]string{},
+		clients:     map[string]*Client{},
+	}
+}
+
+// SetTenants updates accrual system addresses of tenants
+func (rt *Router) SetTenants(tenants []models.Tenant) {
+	addrs := make(map[uint64]string, len(tenants))
+	for _, tenant := range tenants {
+		if tenant.AccrualAddress != "" {
+			addrs[tenant.ID] = tenant.AccrualAddress
+		}
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.addrs = addrs
+}
+
+// GetOrderAccrual returns order accrual calculated by accrual system of tenant
+func (rt *Router) GetOrderAccrual(ctx context.Context, tenantID uint64, number string) (*models.Accrual, error) {
+	return rt.client(tenantID).GetOrderAccrual(ctx, number)
+}
+
+// client returns client of tenant accrual system, clients are created on first use
+func (rt *Router) client(tenantID uint64) *Client {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	addr, ok := rt.addrs[tenantID]
+	if !ok {
+		addr = rt.defaultAddr
+	}
+
+	client, ok := rt.clients[addr]
+	if !ok {
+		client = NewClient(addr)
+		rt.clients[addr] = client
+	}
+
+	return client
+}
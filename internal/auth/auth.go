@@ -5,6 +5,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/rookgm/gophermart/internal/models"
+	"sync"
 	"time"
 )
 
@@ -16,32 +17,98 @@ var (
 )
 
 type AuthToken struct {
-	key []byte
+	mu   sync.RWMutex
+	keys *KeySet
+	ttl  time.Duration
 }
 
-func NewAuthToken(key []byte) *AuthToken {
-	return &AuthToken{key: key}
+// NewAuthToken creates AuthToken issuing tokens signed by keys and valid for ttl
+func NewAuthToken(keys *KeySet, ttl time.Duration) *AuthToken {
+	return &AuthToken{keys: keys, ttl: ttl}
 }
 
-// CreateToken creates new user token
-func (at *AuthToken) CreateToken(user *models.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+// SetKeys replaces signing keys, e.g. after rotation
+func (at *AuthToken) SetKeys(keys *KeySet) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	at.keys = keys
+}
+
+// keySet returns current signing keys
+func (at *AuthToken) keySet() *KeySet {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+
+	return at.keys
+}
+
+// CreateToken creates new user token and returns it with its payload
+func (at *AuthToken) CreateToken(user *models.User) (string, *models.TokenPayload, error) {
+	now := time.Now().Truncate(time.Second)
+	payload := &models.TokenPayload{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		Role:      user.Role,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(at.ttl),
+	}
+
+	key := at.keySet().Current()
+	method, err := key.method()
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := jwt.NewWithClaims(method,
 		jwt.MapClaims{
-			"uuid":   uuid.New().String(),
-			"userid": user.ID,
-			"exp":    time.Now().Add(24 * time.Hour).Unix(),
+			"uuid":   payload.ID.String(),
+			"userid": payload.UserID,
+			"tenant": payload.TenantID,
+			"role":   payload.Role,
+			"iat":    payload.IssuedAt.Unix(),
+			"exp":    payload.ExpiresAt.Unix(),
 		})
+	token.Header["kid"] = key.ID
+
+	signed, err := token.SignedString(key.signingKey())
+	if err != nil {
+		return "", nil, err
+	}
+
+	return signed, payload, nil
+}
 
-	return token.SignedString(at.key)
+// JWKS returns public keys which can be used by other services to verify tokens
+func (at *AuthToken) JWKS() JWKS {
+	return at.keySet().JWKS()
 }
 
 // VerifyToken verifies token and return payload
 func (at *AuthToken) VerifyToken(tokenString string) (*models.TokenPayload, error) {
+	keys := at.keySet()
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		// tokens issued before key ids were introduced are verified by current key
+		key := keys.Current()
+		if kid, ok := token.Header["kid"].(string); ok {
+			var err error
+			if key, err = keys.Key(kid); err != nil {
+				return nil, err
+			}
+		}
+
+		// token must be signed by algorithm of the key, otherwise public key
+		// could be used as HMAC secret
+		method, err := key.method()
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != method.Alg() {
 			return nil, ErrTokenSigningMethod
 		}
-		return at.key, nil
+
+		return key.verificationKey(), nil
 	})
 
 	if err != nil {
@@ -78,8 +145,34 @@ func (at *AuthToken) VerifyToken(tokenString string) (*models.TokenPayload, erro
 	// convert userid to int
 	userID := uint64(userIDFloat)
 
-	return &models.TokenPayload{
-		ID:     id,
-		UserID: userID,
-	}, nil
+	// get expiration time
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, ErrTokenPayload
+	}
+
+	payload := &models.TokenPayload{
+		ID:        id,
+		UserID:    userID,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}
+
+	// tokens issued before roles were introduced belong to ordinary users
+	payload.Role = models.RoleUser
+	if role, ok := claims["role"].(string); ok {
+		payload.Role = role
+	}
+
+	// tokens issued before tenants were introduced belong to default tenant
+	payload.TenantID = models.DefaultTenantID
+	if tenantID, ok := claims["tenant"].(float64); ok {
+		payload.TenantID = uint64(tenantID)
+	}
+
+	// tokens issued before issue time was introduced have zero issue time
+	if iat, ok := claims["iat"].(float64); ok {
+		payload.IssuedAt = time.Unix(int64(iat), 0)
+	}
+
+	return payload, nil
 }
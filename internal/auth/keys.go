@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// keySize is size of generated HMAC key in bytes
+	keySize = 32
+	// rsaKeyBits is size of generated RSA key in bits
+	rsaKeyBits = 2048
+)
+
+// token signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+var (
+	ErrKeyNotFound  = errors.New("signing key not found")
+	ErrNoKeys       = errors.New("key set has no keys")
+	ErrKeyAlgorithm = errors.New("unsupported key algorithm")
+	ErrInvalidKey   = errors.New("invalid signing key")
+)
+
+// Key is token signing key identified by id. HS256 keys have Secret,
+// EdDSA and RS256 keys have PrivateKey, whose public part may be published.
+type Key struct {
+	ID         string
+	Algorithm  string
+	Secret     []byte
+	PrivateKey crypto.Signer
+	CreatedAt  time.Time
+}
+
+// method returns JWT signing method of key
+func (k Key) method() (jwt.SigningMethod, error) {
+	switch k.Algorithm {
+	case AlgHS256:
+		return jwt.SigningMethodHS256, nil
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	}
+	return nil, ErrKeyAlgorithm
+}
+
+// signingKey returns key used for signing tokens
+func (k Key) signingKey() interface{} {
+	if k.PrivateKey != nil {
+		return k.PrivateKey
+	}
+	return k.Secret
+}
+
+// verificationKey returns key used for verifying tokens
+func (k Key) verificationKey() interface{} {
+	if k.PrivateKey != nil {
+		return k.PrivateKey.Public()
+	}
+	return k.Secret
+}
+
+// KeySet is set of signing keys. Tokens are signed by current key and
+// verified by any key of the set, so old tokens stay valid during rotation.
+type KeySet struct {
+	current string
+	keys    map[string]Key
+}
+
+// NewKeySet creates KeySet with current key and additional verification keys
+func NewKeySet(current Key, keys ...Key) *KeySet {
+	ks := &KeySet{
+		current: current.ID,
+		keys:    map[string]Key{current.ID: current},
+	}
+	for _, key := range keys {
+		ks.keys[key.ID] = key
+	}
+	return ks
+}
+
+// GenerateKey generates new random key for algorithm alg
+func GenerateKey(alg string) (Key, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, err
+	}
+
+	key := Key{
+		ID:        hex.EncodeToString(id),
+		Algorithm: alg,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	switch alg {
+	case AlgHS256:
+		key.Secret = make([]byte, keySize)
+		if _, err := rand.Read(key.Secret); err != nil {
+			return Key{}, err
+		}
+	case AlgEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return Key{}, err
+		}
+		key.PrivateKey = private
+	case AlgRS256:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return Key{}, err
+		}
+		key.PrivateKey = private
+	default:
+		return Key{}, ErrKeyAlgorithm
+	}
+
+	return key, nil
+}
+
+// Current returns key used for signing
+func (ks *KeySet) Current() Key {
+	return ks.keys[ks.current]
+}
+
+// Key returns key by id
+func (ks *KeySet) Key(id string) (Key, error) {
+	key, ok := ks.keys[id]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// Keys returns all keys, newest first
+func (ks *KeySet) Keys() []Key {
+	keys := make([]Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// Rotate adds new key for algorithm alg and makes it current. Previous keys are kept for verification.
+func (ks *KeySet) Rotate(alg string) (Key, error) {
+	key, err := GenerateKey(alg)
+	if err != nil {
+		return Key{}, err
+	}
+
+	ks.keys[key.ID] = key
+	ks.current = key.ID
+
+	return key, nil
+}
+
+// Prune removes all but keep newest keys. Current key is never removed.
+func (ks *KeySet) Prune(keep int) []Key {
+	var removed []Key
+	for i, key := range ks.Keys() {
+		if i < keep || key.ID == ks.current {
+			continue
+		}
+		delete(ks.keys, key.ID)
+		removed = append(removed, key)
+	}
+	return removed
+}
+
+// keyFile is JSON key file format
+type keyFile struct {
+	Current string        `json:"current"`
+	Keys    []keyFileItem `json:"keys"`
+}
+
+// keyFileItem is key of key file. Secret is hex encoded, private key is PEM encoded PKCS #8.
+// Keys without algorithm are HS256 keys.
+type keyFileItem struct {
+	ID         string    `json:"kid"`
+	Algorithm  string    `json:"alg,omitempty"`
+	Secret     string    `json:"secret,omitempty"`
+	PrivateKey string    `json:"private_key,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// LoadKeySet reads KeySet from JSON key file
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, err
+	}
+
+	if len(kf.Keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	ks := &KeySet{
+		current: kf.Current,
+		keys:    make(map[string]Key, len(kf.Keys)),
+	}
+	for _, item := range kf.Keys {
+		key, err := item.key()
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[key.ID] = key
+	}
+
+	if _, ok := ks.keys[ks.current]; !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return ks, nil
+}
+
+// Save writes KeySet to JSON key file readable by owner only
+func (ks *KeySet) Save(path string) error {
+	kf := keyFile{Current: ks.current}
+	for _, key := range ks.Keys() {
+		item, err := newKeyFileItem(key)
+		if err != nil {
+			return err
+		}
+		kf.Keys = append(kf.Keys, item)
+	}
+
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to temporary file first, so readers never see partially written file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// newKeyFileItem encodes key for key file
+func newKeyFileItem(key Key) (keyFileItem, error) {
+	item := keyFileItem{
+		ID:        key.ID,
+		Algorithm: key.Algorithm,
+		CreatedAt: key.CreatedAt,
+	}
+
+	if key.PrivateKey == nil {
+		item.Secret = hex.EncodeToString(key.Secret)
+		return item, nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return keyFileItem{}, err
+	}
+	item.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	return item, nil
+}
+
+// key decodes key of key file
+func (item keyFileItem) key() (Key, error) {
+	key := Key{ID: item.ID, Algorithm: item.Algorithm, CreatedAt: item.CreatedAt}
+
+	switch item.Algorithm {
+	case "":
+		key.Algorithm = AlgHS256
+		fallthrough
+	case AlgHS256:
+		secret, err := hex.DecodeString(item.Secret)
+		if err != nil {
+			return Key{}, err
+		}
+		key.Secret = secret
+		return key, nil
+	case AlgEdDSA, AlgRS256:
+	default:
+		return Key{}, ErrKeyAlgorithm
+	}
+
+	block, _ := pem.Decode([]byte(item.PrivateKey))
+	if block == nil {
+		return Key{}, ErrInvalidKey
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return Key{}, err
+	}
+
+	// key type must match algorithm, so key can not be used with another signing method
+	switch private := private.(type) {
+	case ed25519.PrivateKey:
+		if item.Algorithm != AlgEdDSA {
+			return Key{}, ErrKeyAlgorithm
+		}
+		key.PrivateKey = private
+	case *rsa.PrivateKey:
+		if item.Algorithm != AlgRS256 {
+			return Key{}, ErrKeyAlgorithm
+		}
+		key.PrivateKey = private
+	default:
+		return Key{}, ErrKeyAlgorithm
+	}
+
+	return key, nil
+}
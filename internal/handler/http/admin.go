@@ -0,0 +1,490 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AdminUserService is interface for back-office user lookup
+type AdminUserService interface {
+	// GetUser returns user by id
+	GetUser(ctx context.Context, userID uint64) (*models.User, error)
+	// SearchUsers returns users of tenant which login starts with prefix
+	SearchUsers(ctx context.Context, tenantID uint64, prefix string) ([]models.User, error)
+}
+
+// AdminOrderService is interface for back-office order management
+type AdminOrderService interface {
+	// ListUserOrders returns page of user orders matching filter, newest first, or all of them if limit is zero
+	ListUserOrders(ctx context.Context, userID uint64, filter models.OrderFilter, limit int) (*models.OrderPage, error)
+	// Requeue returns order of tenant to accrual queue
+	Requeue(ctx context.Context, actorID uint64, tenantID uint64, number string) (*models.Order, error)
+}
+
+// AdminBalanceService is interface for back-office balance management
+type AdminBalanceService interface {
+	// GetUserBalance returns user balance
+	GetUserBalance(ctx context.Context, userID uint64) (*models.Balance, error)
+	// ListUserWithdrawals returns list of user withdrawals
+	ListUserWithdrawals(ctx context.Context, userID uint64) ([]models.Withdrawal, error)
+	// Adjust applies manual balance adjustment
+	Adjust(ctx context.Context, tenantID uint64, adj *models.Adjustment) (*models.Adjustment, error)
+}
+
+// AdminAuditService is interface for reading audit log
+type AdminAuditService interface {
+	// ListEvents returns events matching filter, newest first
+	ListEvents(ctx context.Context, filter models.AuditFilter, limit int) ([]models.AuditEvent, error)
+}
+
+const (
+	// defaultAuditPageSize is number of audit events returned if limit is not set
+	defaultAuditPageSize = 50
+	// maxAuditPageSize is maximum number of audit events returned at once
+	maxAuditPageSize = 500
+)
+
+// AdminHandler represents HTTP handler for back-office requests
+type AdminHandler struct {
+	userSvc    AdminUserService
+	orderSvc   AdminOrderService
+	balanceSvc AdminBalanceService
+	auditSvc   AdminAuditService
+}
+
+// NewAdminHandler creates new AdminHandler instance
+func NewAdminHandler(us AdminUserService, os AdminOrderService, bs AdminBalanceService, as AdminAuditService) *AdminHandler {
+	return &AdminHandler{userSvc: us, orderSvc: os, balanceSvc: bs, auditSvc: as}
+}
+
+type AdminUserResp struct {
+	ID        uint64 `json:"id"`
+	Login     string `json:"login"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+type AdminOrderResp struct {
+	Number     string   `json:"number"`
+	Status     string   `json:"status"`
+	Accrual    *float64 `json:"accrual,omitempty"`
+	UploadedAt string   `json:"uploaded_at"`
+}
+
+type AdjustmentResp struct {
+	ID        uint64  `json:"id"`
+	UserID    uint64  `json:"user_id"`
+	ActorID   uint64  `json:"actor_id"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type AuditEventResp struct {
+	ID        uint64                 `json:"id"`
+	Type      string                 `json:"type"`
+	ActorID   *uint64                `json:"actor_id,omitempty"`
+	UserID    *uint64                `json:"user_id,omitempty"`
+	IP        string                 `json:"ip,omitempty"`
+	UserAgent string                 `json:"user_agent,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Details   map[string]interface{} `json:"details"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+// AuditPageResp is page of audit events. NextBefore is passed as before parameter to get next page.
+type AuditPageResp struct {
+	Events     []AuditEventResp `json:"events"`
+	NextBefore uint64           `json:"next_before"`
+}
+
+// adjustmentRequest is manual balance adjustment data, negative amount debits user balance
+type adjustmentRequest struct {
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+// SearchUsers finds users of tenant by login prefix given in login query parameter
+// 200 — успешная обработка запроса;
+// 204 — пользователи не найдены;
+// 400 — не задан логин;
+// 500 — внутренняя ошибка сервера.
+func (ah *AdminHandler) SearchUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		login := r.URL.Query().Get("login")
+		if login == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		users, err := ah.userSvc.SearchUsers(r.Context(), tenantID, login)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		if len(users) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		usersResp := make([]AdminUserResp, 0, len(users))
+		for _, user := range users {
+			usersResp = append(usersResp, AdminUserResp{
+				ID:        user.ID,
+				Login:     user.Login,
+				Role:      user.Role,
+				CreatedAt: user.CreatedAt.Format(time.RFC3339),
+			})
+		}
+
+		writeJSON(w, http.StatusOK, usersResp)
+	}
+}
+
+// GetUserBalance gets balance of user
+// 200 — успешная обработка запроса;
+// 400 — неверный идентификатор пользователя;
+// 404 — пользователь не найден;
+// 500 — внутренняя ошибка сервера.
+func (ah *AdminHandler) GetUserBalance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := ah.tenantUserID(w, r)
+		if !ok {
+			return
+		}
+
+		balance, err := ah.balanceSvc.GetUserBalance(r.Context(), userID)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, BalanceResp{
+			Current:      balance.Current,
+			Withdrawn:    balance.Withdrawn,
+			ExpiringSoon: balance.ExpiringSoon,
+		})
+	}
+}
+
+// ListUserOrders gets page of user orders, accepting the same query parameters as user orders list
+// 200 — успешная обработка запроса;
+// 204 — нет данных для ответа;
+// 400 — неверный идентификатор пользователя или параметры запроса;
+// 404 — пользователь не найден;
+// 500 — внутренняя ошибка сервера.
+func (ah *AdminHandler) ListUserOrders() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := ah.tenantUserID(w, r)
+		if !ok {
+			return
+		}
+
+		filter, limit, err := orderPageParams(r)
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		page, err := ah.orderSvc.ListUserOrders(r.Context(), userID, filter, limit)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		if len(page.Orders) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		ordersResp := make([]AdminOrderResp, 0, len(page.Orders))
+		for _, order := range page.Orders {
+			ordersResp = append(ordersResp, AdminOrderResp{
+				Number:     order.Number,
+				Status:     order.Status,
+				Accrual:    order.Accrual,
+				UploadedAt: order.UploadedAt.Format(time.RFC3339),
+			})
+		}
+
+		setNextPageHeaders(w, r, page.Next)
+		writeJSON(w, http.StatusOK, ordersResp)
+	}
+}
+
+// ListUserWithdrawals gets withdrawals of user
+// 200 — успешная обработка запроса;
+// 204 — нет ни одного списания;
+// 400 — неверный идентификатор пользователя;
+// 404 — пользователь не найден;
+// 500 — внутренняя ошибка сервера.
+func (ah *AdminHandler) ListUserWithdrawals() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := ah.tenantUserID(w, r)
+		if !ok {
+			return
+		}
+
+		withdrawals, err := ah.balanceSvc.ListUserWithdrawals(r.Context(), userID)
+		if err != nil {
+			if errors.Is(err, models.ErrDataNotFound) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		withdrawalsResp := make([]ListWithdrawalsResp, 0, len(withdrawals))
+		for _, withdrawal := range withdrawals {
+			withdrawalsResp = append(withdrawalsResp, ListWithdrawalsResp{
+				Order:       withdrawal.Order,
+				Sum:         withdrawal.Sum,
+				ProcessedAt: withdrawal.ProcessedAt.Format(time.RFC3339),
+			})
+		}
+
+		writeJSON(w, http.StatusOK, withdrawalsResp)
+	}
+}
+
+// RequeueOrder returns order of tenant to accrual queue
+// 202 — заказ возвращён в очередь начисления;
+// 404 — заказ не найден;
+// 409 — начисление по заказу уже выполнено;
+// 500 — внутренняя ошибка сервера.
+func (ah *AdminHandler) RequeueOrder() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract id of back-office user
+		actorID, ok := r.Context().Value("userid").(uint64)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		_, err := ah.orderSvc.Requeue(r.Context(), actorID, tenantID, chi.URLParam(r, "number"))
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrDataNotFound):
+				http.Error(w, "order not found", http.StatusNotFound)
+			case errors.Is(err, models.ErrOrderProcessed):
+				http.Error(w, "order has already been processed", http.StatusConflict)
+			default:
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// AdjustBalance applies manual balance adjustment with mandatory reason
+// 200 — корректировка выполнена;
+// 400 — неверный формат запроса, нулевая сумма или не указана причина;
+// 404 — пользователь не найден;
+// 422 — списание превышает баланс пользователя;
+// 500 — внутренняя ошибка сервера.
+func (ah *AdminHandler) AdjustBalance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract id of back-office user
+		actorID, ok := r.Context().Value("userid").(uint64)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		userID, ok := ah.tenantUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var adjReq adjustmentRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&adjReq); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		adj, err := ah.balanceSvc.Adjust(r.Context(), tenantID, &models.Adjustment{
+			UserID:  userID,
+			ActorID: actorID,
+			Amount:  adjReq.Amount,
+			Reason:  adjReq.Reason,
+		})
+		if err != nil {
+			var validationErr *models.ValidationError
+			switch {
+			case errors.As(err, &validationErr):
+				writeValidationError(w, validationErr)
+			case errors.Is(err, models.ErrDataNotFound):
+				http.Error(w, "user not found", http.StatusNotFound)
+			case errors.Is(err, models.ErrInsufficientFunds):
+				http.Error(w, "insufficient funds", http.StatusUnprocessableEntity)
+			default:
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, AdjustmentResp{
+			ID:        adj.ID,
+			UserID:    adj.UserID,
+			ActorID:   adj.ActorID,
+			Amount:    adj.Amount,
+			Reason:    adj.Reason,
+			CreatedAt: adj.CreatedAt.Format(time.RFC3339),
+		})
+	}
+}
+
+// ListAuditEvents pages through audit log of tenant, newest first. Events may be filtered by type and user_id,
+// page is selected by before and limit query parameters.
+// 200 — успешная обработка запроса;
+// 204 — нет событий;
+// 400 — неверные параметры запроса;
+// 500 — внутренняя ошибка сервера.
+func (ah *AdminHandler) ListAuditEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		query := r.URL.Query()
+
+		filter := models.AuditFilter{TenantID: tenantID, Type: query.Get("type")}
+		limit := defaultAuditPageSize
+
+		var err error
+		if before := query.Get("before"); before != "" {
+			if filter.Before, err = strconv.ParseUint(before, 10, 64); err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+		}
+		if userID := query.Get("user_id"); userID != "" {
+			if filter.UserID, err = strconv.ParseUint(userID, 10, 64); err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+		}
+		if limitParam := query.Get("limit"); limitParam != "" {
+			limit, err = strconv.Atoi(limitParam)
+			if err != nil || limit <= 0 || limit > maxAuditPageSize {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+		}
+
+		events, err := ah.auditSvc.ListEvents(r.Context(), filter, limit)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		if len(events) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		eventsResp := make([]AuditEventResp, 0, len(events))
+		for _, event := range events {
+			eventsResp = append(eventsResp, AuditEventResp{
+				ID:        event.ID,
+				Type:      event.Type,
+				ActorID:   event.ActorID,
+				UserID:    event.UserID,
+				IP:        event.IP,
+				UserAgent: event.UserAgent,
+				RequestID: event.RequestID,
+				Details:   event.Details,
+				CreatedAt: event.CreatedAt.Format(time.RFC3339),
+			})
+		}
+
+		writeJSON(w, http.StatusOK, AuditPageResp{
+			Events:     eventsResp,
+			NextBefore: events[len(events)-1].ID,
+		})
+	}
+}
+
+// tenantUserID returns id of user given in URL path. User of another tenant is reported
+// as not found. Returns false if response has been written.
+func (ah *AdminHandler) tenantUserID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	tenantID, ok := requestTenantID(r)
+	if !ok {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	userID, ok := userIDParam(r)
+	if !ok {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return 0, false
+	}
+
+	user, err := ah.userSvc.GetUser(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, models.ErrDataNotFound) {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return 0, false
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if user.TenantID != tenantID {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// userIDParam returns user id given in URL path
+func userIDParam(r *http.Request) (uint64, bool) {
+	userID, err := strconv.ParseUint(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return userID, true
+}
+
+// writeJSON responds with status and value encoded as JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		return
+	}
+}
@@ -5,13 +5,31 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/service"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+const (
+	accessTokenCookie  = "auth_token"
+	refreshTokenCookie = "refresh_token"
+	// refreshTokenPath limits refresh token cookie to user API
+	refreshTokenPath = "/api/user"
+	tokenTypeBearer  = "Bearer"
+)
+
 // AuthService is interface for interfacing with user authentication
 type AuthService interface {
-	Login(ctx context.Context, login string, password string) (string, error)
+	// Login authenticates user of tenant connected from ip and issues tokens
+	Login(ctx context.Context, tenantID uint64, login string, password string, ip string) (*models.TokenPair, error)
+	// CreateSession issues tokens to user
+	CreateSession(ctx context.Context, user *models.User) (*models.TokenPair, error)
+	// Refresh rotates refresh token of tenant user and issues new access token
+	Refresh(ctx context.Context, tenantID uint64, refreshToken string) (*models.TokenPair, error)
+	// Logout revokes user tokens
+	Logout(ctx context.Context, payload *models.TokenPayload, refreshToken string) error
 }
 
 // AuthHandler represents HTTP handler for user-related requests
@@ -30,13 +48,33 @@ type loginRequest struct {
 	Password string `json:"password"`
 }
 
+// tokenResponse is issued tokens, returned to clients which do not use cookies
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// refreshRequest is refresh token given in request body
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // LoginUser perform user logging
 // 200 — пользователь успешно аутентифицирован;
 // 400 — неверный формат запроса;
 // 401 — неверная пара логин/пароль;
+// 429 — слишком много неудачных попыток, вход временно заблокирован;
 // 500 — внутренняя ошибка сервера.
 func (ah *AuthHandler) LoginUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
 		var loginReq loginRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
@@ -45,24 +83,155 @@ func (ah *AuthHandler) LoginUser() http.HandlerFunc {
 		}
 		defer r.Body.Close()
 
-		token, err := ah.authSvc.Login(r.Context(), loginReq.Login, loginReq.Password)
+		tokens, err := ah.authSvc.Login(r.Context(), tenantID, loginReq.Login, loginReq.Password, service.RequestInfoFromContext(r.Context()).IP)
 		if err != nil {
 			if errors.Is(err, models.ErrInvalidCredentials) {
 				http.Error(w, "incorrect login or password", http.StatusUnauthorized)
 				return
 			}
+			var lockoutErr *models.LockoutError
+			if errors.As(err, &lockoutErr) {
+				// round up, so client does not retry before lockout ends
+				retryAfter := int64(math.Ceil(lockoutErr.RetryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+				http.Error(w, "too many login attempts", http.StatusTooManyRequests)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeTokens(w, tokens)
+	}
+}
+
+// RefreshToken rotates refresh token and issues new access token
+// 200 — токены успешно обновлены;
+// 401 — refresh-токен отсутствует, недействителен или отозван;
+// 500 — внутренняя ошибка сервера.
+func (ah *AuthHandler) RefreshToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := requestTenantID(r)
+		if !ok {
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "auth_token",
-			Value:    token,
-			Path:     "/",
-			Expires:  time.Now().Add(24 * time.Hour),
-			HttpOnly: true,
-		})
+		refreshToken := refreshTokenFromRequest(r)
+		if refreshToken == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		tokens, err := ah.authSvc.Refresh(r.Context(), tenantID, refreshToken)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidRefreshToken) {
+				clearAuthCookies(w)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeTokens(w, tokens)
+	}
+}
+
+// Logout revokes user tokens
+// 200 — пользователь успешно вышел;
+// 401 — пользователь не авторизован;
+// 500 — внутренняя ошибка сервера.
+func (ah *AuthHandler) Logout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract token payload
+		payload, ok := r.Context().Value("token").(*models.TokenPayload)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		if err := ah.authSvc.Logout(r.Context(), payload, refreshTokenFromRequest(r)); err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		clearAuthCookies(w)
 
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// refreshTokenFromRequest returns refresh token given in cookie or in request body
+func refreshTokenFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie(refreshTokenCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	var refreshReq refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&refreshReq); err != nil {
+		return ""
+	}
+	defer r.Body.Close()
+
+	return refreshReq.RefreshToken
+}
+
+// writeTokens responds with issued tokens. Tokens are set as cookies for browsers, access token
+// is also returned in Authorization header and both tokens in response body for other clients.
+func writeTokens(w http.ResponseWriter, tokens *models.TokenPair) {
+	setAuthCookies(w, tokens)
+
+	resp, err := json.Marshal(tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    tokenTypeBearer,
+		ExpiresIn:    int64(time.Until(tokens.AccessExpiresAt).Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	})
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Authorization", tokenTypeBearer+" "+tokens.AccessToken)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// setAuthCookies sets access and refresh token cookies
+func setAuthCookies(w http.ResponseWriter, tokens *models.TokenPair) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessTokenCookie,
+		Value:    tokens.AccessToken,
+		Path:     "/",
+		Expires:  tokens.AccessExpiresAt,
+		HttpOnly: true,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    tokens.RefreshToken,
+		Path:     refreshTokenPath,
+		Expires:  tokens.RefreshExpiresAt,
+		HttpOnly: true,
+	})
+}
+
+// clearAuthCookies removes access and refresh token cookies
+func clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessTokenCookie,
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Path:     refreshTokenPath,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
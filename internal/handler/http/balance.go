@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http"
+	"time"
+)
+
+// BalanceService is interface for interfacing with balance-related logic
+type BalanceService interface {
+	// GetUserBalance returns user balance
+	GetUserBalance(ctx context.Context, userID uint64) (*models.Balance, error)
+	// Withdraw debits user balance in favor of order
+	Withdraw(ctx context.Context, tenantID uint64, withdrawal *models.Withdrawal) (*models.Withdrawal, error)
+	// ListUserWithdrawals returns list of user withdrawals
+	ListUserWithdrawals(ctx context.Context, userID uint64) ([]models.Withdrawal, error)
+}
+
+// BalanceHandler represents HTTP handler for balance-related requests
+type BalanceHandler struct {
+	svc BalanceService
+}
+
+// NewBalanceHandler creates new BalanceHandler instance
+func NewBalanceHandler(svc BalanceService) *BalanceHandler {
+	return &BalanceHandler{svc: svc}
+}
+
+type BalanceResp struct {
+	Current      float64 `json:"current"`
+	Withdrawn    float64 `json:"withdrawn"`
+	ExpiringSoon float64 `json:"expiring_soon"`
+}
+
+// GetBalance gets user balance
+// 200 — успешная обработка запроса.
+// 401 — пользователь не авторизован.
+// 500 — внутренняя ошибка сервера.
+func (bh *BalanceHandler) GetBalance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract user id
+		userID, ok := r.Context().Value("userid").(uint64)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		balance, err := bh.svc.GetUserBalance(r.Context(), userID)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(BalanceResp{
+			Current:      balance.Current,
+			Withdrawn:    balance.Withdrawn,
+			ExpiringSoon: balance.ExpiringSoon,
+		}); err != nil {
+			return
+		}
+	}
+}
+
+// withdrawRequest is withdrawal request data
+type withdrawRequest struct {
+	Order string  `json:"order"`
+	Sum   float64 `json:"sum"`
+}
+
+// Withdraw debits user balance in favor of new order
+// 200 — успешная обработка запроса;
+// 401 — пользователь не авторизован;
+// 402 — на счету недостаточно средств;
+// 422 — неверный номер заказа;
+// 500 — внутренняя ошибка сервера.
+func (bh *BalanceHandler) Withdraw() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract user id
+		userID, ok := r.Context().Value("userid").(uint64)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		var withdrawReq withdrawRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&withdrawReq); err != nil || withdrawReq.Sum <= 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		_, err := bh.svc.Withdraw(r.Context(), tenantID, &models.Withdrawal{
+			UserID: userID,
+			Order:  withdrawReq.Order,
+			Sum:    withdrawReq.Sum,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrInvalidOrderID):
+				http.Error(w, "invalid order number", http.StatusUnprocessableEntity)
+			case errors.Is(err, models.ErrInsufficientFunds):
+				http.Error(w, "insufficient funds", http.StatusPaymentRequired)
+			default:
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+type ListWithdrawalsResp struct {
+	Order       string  `json:"order"`
+	Sum         float64 `json:"sum"`
+	ProcessedAt string  `json:"processed_at"`
+}
+
+// ListWithdrawals gets list of user withdrawals
+// 200 — успешная обработка запроса.
+// 204 — нет ни одного списания.
+// 401 — пользователь не авторизован.
+// 500 — внутренняя ошибка сервера.
+func (bh *BalanceHandler) ListWithdrawals() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract user id
+		userID, ok := r.Context().Value("userid").(uint64)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		// get user withdrawals
+		withdrawals, err := bh.svc.ListUserWithdrawals(r.Context(), userID)
+		if err != nil {
+			if errors.Is(err, models.ErrDataNotFound) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		var withdrawalsResp []ListWithdrawalsResp
+
+		for _, withdrawal := range withdrawals {
+			withdrawalsResp = append(withdrawalsResp, ListWithdrawalsResp{
+				Order:       withdrawal.Order,
+				Sum:         withdrawal.Sum,
+				ProcessedAt: withdrawal.ProcessedAt.Format(time.RFC3339),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(withdrawalsResp); err != nil {
+			return
+		}
+	}
+}
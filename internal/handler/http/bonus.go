@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http"
+	"time"
+)
+
+// BonusService is interface for interfacing with bonus-related logic
+type BonusService interface {
+	// ListUserBonuses returns bonuses credited to user
+	ListUserBonuses(ctx context.Context, userID uint64) ([]models.Bonus, error)
+}
+
+// BonusHandler represents HTTP handler for bonus-related requests
+type BonusHandler struct {
+	svc BonusService
+}
+
+// NewBonusHandler creates new BonusHandler instance
+func NewBonusHandler(svc BonusService) *BonusHandler {
+	return &BonusHandler{svc: svc}
+}
+
+type ListBonusesResp struct {
+	Rule       string  `json:"rule"`
+	Order      *string `json:"order,omitempty"`
+	Amount     float64 `json:"amount"`
+	CreditedAt string  `json:"credited_at"`
+}
+
+// ListBonuses gets list of bonuses credited to user
+// 200 — успешная обработка запроса.
+// 204 — нет данных для ответа.
+// 401 — пользователь не авторизован.
+// 500 — внутренняя ошибка сервера.
+func (bh *BonusHandler) ListBonuses() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract user id
+		userID, ok := r.Context().Value("userid").(uint64)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		bonuses, err := bh.svc.ListUserBonuses(r.Context(), userID)
+		if err != nil {
+			if errors.Is(err, models.ErrDataNotFound) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		var bonusesResp []ListBonusesResp
+
+		for _, bonus := range bonuses {
+			bonusesResp = append(bonusesResp, ListBonusesResp{
+				Rule:       bonus.RuleName,
+				Order:      bonus.Order,
+				Amount:     bonus.Amount,
+				CreditedAt: bonus.CreatedAt.Format(time.RFC3339),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(bonusesResp); err != nil {
+			return
+		}
+	}
+}
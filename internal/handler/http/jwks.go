@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rookgm/gophermart/internal/auth"
+	"net/http"
+)
+
+// jwksMaxAge is how long clients may cache published keys, in seconds
+const jwksMaxAge = "300"
+
+// KeyProvider is interface for getting public token verification keys
+type KeyProvider interface {
+	// JWKS returns public keys of token signing keys
+	JWKS() auth.JWKS
+}
+
+// JWKSHandler represents HTTP handler publishing token verification keys
+type JWKSHandler struct {
+	keys KeyProvider
+}
+
+// NewJWKSHandler creates new JWKSHandler instance
+func NewJWKSHandler(keys KeyProvider) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetKeys gets public keys used to verify user tokens
+// 200 — успешная обработка запроса.
+// 500 — внутренняя ошибка сервера.
+func (jh *JWKSHandler) GetKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := json.Marshal(jh.keys.JWKS())
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age="+jwksMaxAge)
+		w.WriteHeader(http.StatusOK)
+		w.Write(resp)
+	}
+}
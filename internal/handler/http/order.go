@@ -1,20 +1,33 @@
 package handler
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"github.com/rookgm/gophermart/internal/models"
 	"io"
+	"mime"
 	"net/http"
+	"strings"
 	"time"
 )
 
+const (
+	// maxBatchOrders is maximum number of orders uploaded at once
+	maxBatchOrders = 1000
+	// maxBatchBodySize is maximum size of batch upload request body
+	maxBatchBodySize = 1 << 20
+)
+
 type OrderService interface {
 	// Upload uploads user order
 	Upload(ctx context.Context, order *models.Order) (*models.Order, error)
-	// ListUserOrders returns list of user orders
-	ListUserOrders(ctx context.Context, userID uint64) ([]models.Order, error)
+	// UploadBatch uploads user orders and returns upload result of each number
+	UploadBatch(ctx context.Context, tenantID uint64, userID uint64, numbers []string) ([]models.OrderUpload, error)
+	// ListUserOrders returns page of user orders matching filter, newest first, or all of them if limit is zero
+	ListUserOrders(ctx context.Context, userID uint64, filter models.OrderFilter, limit int) (*models.OrderPage, error)
 }
 
 // OrderHandler represents HTTP handler for order-related requests
@@ -43,6 +56,11 @@ func (oh *OrderHandler) UploadOrder() http.HandlerFunc {
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
 		// get order id
 		body, err := io.ReadAll(r.Body)
 		if err != nil || len(body) == 0 {
@@ -52,8 +70,9 @@ func (oh *OrderHandler) UploadOrder() http.HandlerFunc {
 		defer r.Body.Close()
 
 		ord := models.Order{
-			UserID: userID,
-			Number: string(body),
+			TenantID: tenantID,
+			UserID:   userID,
+			Number:   string(body),
 		}
 
 		_, err = oh.svc.Upload(r.Context(), &ord)
@@ -64,7 +83,7 @@ func (oh *OrderHandler) UploadOrder() http.HandlerFunc {
 			case errors.Is(err, models.ErrOrderLoadedUser):
 				http.Error(w, "order has already been uploaded", http.StatusOK)
 			case errors.Is(err, models.ErrOrderLoadedAnotherUser):
-				http.Error(w, "order has already been uploaded by another user", http.StatusUnprocessableEntity)
+				http.Error(w, "order has already been uploaded by another user", http.StatusConflict)
 			default:
 				http.Error(w, "internal error", http.StatusInternalServerError)
 			}
@@ -75,6 +94,87 @@ func (oh *OrderHandler) UploadOrder() http.HandlerFunc {
 	}
 }
 
+type OrderUploadResp struct {
+	Number string `json:"number"`
+	Status string `json:"status"`
+}
+
+// UploadOrders uploads user orders given as JSON array of strings or as newline-delimited text.
+// Each number gets its own status: accepted, already_uploaded, conflict or invalid.
+// 200 — номера заказов обработаны;
+// 400 — неверный формат запроса, нет номеров или их больше 1000;
+// 401 — пользователь не аутентифицирован;
+// 500 — внутренняя ошибка сервера.
+func (oh *OrderHandler) UploadOrders() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract user id
+		userID, ok := r.Context().Value("userid").(uint64)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		numbers, err := batchOrderNumbers(r.Header.Get("Content-Type"), body)
+		if err != nil || len(numbers) == 0 || len(numbers) > maxBatchOrders {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		uploads, err := oh.svc.UploadBatch(r.Context(), tenantID, userID, numbers)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		uploadsResp := make([]OrderUploadResp, 0, len(uploads))
+		for _, upload := range uploads {
+			uploadsResp = append(uploadsResp, OrderUploadResp{
+				Number: upload.Number,
+				Status: upload.Result,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, uploadsResp)
+	}
+}
+
+// batchOrderNumbers parses order numbers given as JSON array or one per line, blank lines are skipped
+func batchOrderNumbers(contentType string, body []byte) ([]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" {
+		var numbers []string
+		if err := json.Unmarshal(body, &numbers); err != nil {
+			return nil, err
+		}
+		return numbers, nil
+	}
+
+	var numbers []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			numbers = append(numbers, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return numbers, nil
+}
+
 type ListOrdersResp struct {
 	Number     string   `json:"number"`
 	Status     string   `json:"status"`
@@ -82,9 +182,12 @@ type ListOrdersResp struct {
 	UploadedAt string   `json:"uploaded_at"`
 }
 
-// ListOrders get list uploaded user orders
+// ListOrders get page of uploaded user orders, newest first. Orders may be filtered by status, from and to
+// query parameters, page is selected by cursor and limit. Link and X-Next-Cursor headers point to the next page.
+// All orders are returned if neither cursor nor limit is set.
 // 200 — успешная обработка запроса.
 // 204 — нет данных для ответа.
+// 400 — неверные параметры запроса.
 // 401 — пользователь не авторизован.
 // 500 — внутренняя ошибка сервера.
 func (oh *OrderHandler) ListOrders() http.HandlerFunc {
@@ -96,20 +199,27 @@ func (oh *OrderHandler) ListOrders() http.HandlerFunc {
 			return
 		}
 
+		filter, limit, err := orderPageParams(r)
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
 		// get user orders
-		orders, err := oh.svc.ListUserOrders(r.Context(), userID)
+		page, err := oh.svc.ListUserOrders(r.Context(), userID, filter, limit)
 		if err != nil {
-			if errors.Is(err, models.ErrDataNotFound) {
-				http.Error(w, "orders not found", http.StatusNoContent)
-				return
-			}
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
 
-		var ordersResp []ListOrdersResp
+		if len(page.Orders) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		ordersResp := make([]ListOrdersResp, 0, len(page.Orders))
 
-		for _, order := range orders {
+		for _, order := range page.Orders {
 			ordersResp = append(ordersResp, ListOrdersResp{
 				Number:     order.Number,
 				Status:     order.Status,
@@ -118,6 +228,8 @@ func (oh *OrderHandler) ListOrders() http.HandlerFunc {
 			})
 		}
 
+		setNextPageHeaders(w, r, page.Next)
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultOrdersPageSize is number of orders returned if cursor is set without limit
+	defaultOrdersPageSize = 100
+	// maxOrdersPageSize is maximum number of orders returned at once
+	maxOrdersPageSize = 1000
+	// nextCursorHeader is header carrying cursor of the next page
+	nextCursorHeader = "X-Next-Cursor"
+	// dateLayout is layout of date given without time
+	dateLayout = "2006-01-02"
+)
+
+var errInvalidPageParams = errors.New("invalid page parameters")
+
+// orderPageParams parses order filter and page size given in query parameters:
+// status (repeated or comma separated), from and to (RFC 3339 time or date), cursor and limit.
+// Date given as to includes the whole day. Page size is zero if neither cursor nor limit is set,
+// so clients unaware of pagination still get all orders.
+func orderPageParams(r *http.Request) (models.OrderFilter, int, error) {
+	query := r.URL.Query()
+
+	var filter models.OrderFilter
+
+	for _, param := range query["status"] {
+		for _, status := range strings.Split(param, ",") {
+			switch status {
+			case models.OrderStatusNew, models.OrderStatusProcessing, models.OrderStatusInvalid, models.OrderStatusProcessed:
+				filter.Statuses = append(filter.Statuses, status)
+			default:
+				return models.OrderFilter{}, 0, errInvalidPageParams
+			}
+		}
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, _, err := parseTimeParam(from)
+		if err != nil {
+			return models.OrderFilter{}, 0, err
+		}
+		filter.From = &t
+	}
+
+	if to := query.Get("to"); to != "" {
+		t, isDate, err := parseTimeParam(to)
+		if err != nil {
+			return models.OrderFilter{}, 0, err
+		}
+		if isDate {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = &t
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := decodeOrderCursor(cursor)
+		if err != nil {
+			return models.OrderFilter{}, 0, err
+		}
+		filter.After = after
+	}
+
+	limit := 0
+	if filter.After != nil {
+		limit = defaultOrdersPageSize
+	}
+	if limitParam := query.Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > maxOrdersPageSize {
+			return models.OrderFilter{}, 0, errInvalidPageParams
+		}
+	}
+
+	return filter, limit, nil
+}
+
+// parseTimeParam parses RFC 3339 time or date, date is midnight UTC
+func parseTimeParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, false, errInvalidPageParams
+	}
+
+	return t, true, nil
+}
+
+// setNextPageHeaders sets Link and X-Next-Cursor headers pointing to the next page.
+// Link keeps query parameters of request, so the next page has the same filter.
+func setNextPageHeaders(w http.ResponseWriter, r *http.Request, next *models.OrderCursor) {
+	if next == nil {
+		return
+	}
+
+	cursor := encodeOrderCursor(next)
+
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+	link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+
+	w.Header().Set(nextCursorHeader, cursor)
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, link.String()))
+}
+
+// encodeOrderCursor encodes cursor as opaque URL-safe string
+func encodeOrderCursor(cursor *models.OrderCursor) string {
+	raw := strconv.FormatInt(cursor.UploadedAt.UnixMicro(), 10) + "." + strconv.FormatUint(cursor.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeOrderCursor decodes cursor encoded by encodeOrderCursor
+func decodeOrderCursor(cursor string) (*models.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidPageParams
+	}
+
+	micros, id, found := strings.Cut(string(raw), ".")
+	if !found {
+		return nil, errInvalidPageParams
+	}
+
+	uploadedAt, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, errInvalidPageParams
+	}
+
+	orderID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, errInvalidPageParams
+	}
+
+	return &models.OrderCursor{UploadedAt: time.UnixMicro(uploadedAt), ID: orderID}, nil
+}
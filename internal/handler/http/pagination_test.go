@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrderCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor models.OrderCursor
+	}{
+		{name: "microsecond time", cursor: models.OrderCursor{UploadedAt: time.Date(2024, 3, 1, 12, 30, 15, 123456000, time.UTC), ID: 42}},
+		{name: "zero id", cursor: models.OrderCursor{UploadedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}},
+		{name: "time before epoch", cursor: models.OrderCursor{UploadedAt: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), ID: 1}},
+		{name: "max id", cursor: models.OrderCursor{UploadedAt: time.Unix(0, 0), ID: 1<<64 - 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeOrderCursor(encodeOrderCursor(&tt.cursor))
+			if err != nil {
+				t.Fatalf("decodeOrderCursor() error = %v", err)
+			}
+			if !got.UploadedAt.Equal(tt.cursor.UploadedAt) || got.ID != tt.cursor.ID {
+				t.Errorf("decodeOrderCursor() = %v, want %v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeOrderCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "!!!"},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte("12.1"))},
+		{name: "no separator", cursor: encode("123")},
+		{name: "time is not number", cursor: encode("x.1")},
+		{name: "id is not number", cursor: encode("1.x")},
+		{name: "negative id", cursor: encode("1.-1")},
+		{name: "empty", cursor: encode("")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeOrderCursor(tt.cursor); !errors.Is(err, errInvalidPageParams) {
+				t.Errorf("decodeOrderCursor() error = %v, want %v", err, errInvalidPageParams)
+			}
+		})
+	}
+}
+
+func TestOrderPageParams(t *testing.T) {
+	cursor := models.OrderCursor{UploadedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ID: 7}
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantFilter models.OrderFilter
+		wantLimit  int
+		wantErr    bool
+	}{
+		{
+			name:      "no parameters return all orders",
+			query:     "",
+			wantLimit: 0,
+		},
+		{
+			name:       "cursor without limit uses default page size",
+			query:      "cursor=" + encodeOrderCursor(&cursor),
+			wantFilter: models.OrderFilter{After: &cursor},
+			wantLimit:  defaultOrdersPageSize,
+		},
+		{
+			name:      "limit",
+			query:     "limit=10",
+			wantLimit: 10,
+		},
+		{
+			name:       "repeated and comma separated statuses",
+			query:      "status=NEW,PROCESSING&status=PROCESSED",
+			wantFilter: models.OrderFilter{Statuses: []string{models.OrderStatusNew, models.OrderStatusProcessing, models.OrderStatusProcessed}},
+		},
+		{
+			name:       "date to includes the whole day",
+			query:      "from=2024-03-01T00:00:00Z&to=2024-03-10",
+			wantFilter: models.OrderFilter{From: &from, To: &to},
+		},
+		{name: "unknown status", query: "status=DONE", wantErr: true},
+		{name: "invalid time", query: "from=yesterday", wantErr: true},
+		{name: "invalid cursor", query: "cursor=!!!", wantErr: true},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+		{name: "limit above max", query: "limit=1001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/user/orders?"+tt.query, nil)
+
+			filter, limit, err := orderPageParams(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("orderPageParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if limit != tt.wantLimit {
+				t.Errorf("orderPageParams() limit = %d, want %d", limit, tt.wantLimit)
+			}
+			if !reflect.DeepEqual(filter.Statuses, tt.wantFilter.Statuses) {
+				t.Errorf("orderPageParams() statuses = %v, want %v", filter.Statuses, tt.wantFilter.Statuses)
+			}
+			if !equalTime(filter.From, tt.wantFilter.From) || !equalTime(filter.To, tt.wantFilter.To) {
+				t.Errorf("orderPageParams() from = %v, to = %v, want %v, %v", filter.From, filter.To, tt.wantFilter.From, tt.wantFilter.To)
+			}
+			if (filter.After == nil) != (tt.wantFilter.After == nil) ||
+				filter.After != nil && (!filter.After.UploadedAt.Equal(tt.wantFilter.After.UploadedAt) || filter.After.ID != tt.wantFilter.After.ID) {
+				t.Errorf("orderPageParams() cursor = %v, want %v", filter.After, tt.wantFilter.After)
+			}
+		})
+	}
+}
+
+// equalTime checks whether both times are nil or equal
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
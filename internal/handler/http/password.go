@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http"
+)
+
+// PasswordService is interface for interfacing with password change and reset
+type PasswordService interface {
+	// ChangePassword changes password of user knowing current password
+	ChangePassword(ctx context.Context, userID uint64, currentPassword string, newPassword string) error
+	// RequestReset issues password reset token and sends it to user of tenant
+	RequestReset(ctx context.Context, tenantID uint64, login string) error
+	// ResetPassword sets new password of user by reset token
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+}
+
+// PasswordHandler represents HTTP handler for password-related requests
+type PasswordHandler struct {
+	passwordSvc PasswordService
+	authSvc     AuthService
+}
+
+// NewPasswordHandler creates new PasswordHandler instance
+func NewPasswordHandler(ps PasswordService, as AuthService) *PasswordHandler {
+	return &PasswordHandler{passwordSvc: ps, authSvc: as}
+}
+
+// changePasswordRequest is password change data
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// resetRequestRequest is password reset request data
+type resetRequestRequest struct {
+	Login string `json:"login"`
+}
+
+// resetPasswordRequest is password reset data
+type resetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ChangePassword changes user password. All user sessions are revoked and new session is issued.
+// 200 — пароль успешно изменён;
+// 400 — неверный формат запроса или новый пароль не соответствует требованиям;
+// 401 — пользователь не авторизован;
+// 403 — неверный текущий пароль;
+// 500 — внутренняя ошибка сервера.
+func (ph *PasswordHandler) ChangePassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// extract token payload
+		payload, ok := r.Context().Value("token").(*models.TokenPayload)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		var changeReq changePasswordRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&changeReq); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		err := ph.passwordSvc.ChangePassword(r.Context(), payload.UserID, changeReq.CurrentPassword, changeReq.NewPassword)
+		if err != nil {
+			var validationErr *models.ValidationError
+			if errors.As(err, &validationErr) {
+				writeValidationError(w, validationErr)
+				return
+			}
+			if errors.Is(err, models.ErrInvalidCredentials) {
+				http.Error(w, "incorrect current password", http.StatusForbidden)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		tokens, err := ph.authSvc.CreateSession(r.Context(), &models.User{ID: payload.UserID, TenantID: payload.TenantID, Role: payload.Role})
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeTokens(w, tokens)
+	}
+}
+
+// RequestReset sends password reset token to user. Response does not depend on whether login exists.
+// 202 — запрос принят;
+// 400 — неверный формат запроса;
+// 500 — внутренняя ошибка сервера;
+// 503 — сброс пароля отключён.
+func (ph *PasswordHandler) RequestReset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		var resetReq resetRequestRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&resetReq); err != nil || resetReq.Login == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		if err := ph.passwordSvc.RequestReset(r.Context(), tenantID, resetReq.Login); err != nil {
+			if errors.Is(err, models.ErrPasswordResetDisabled) {
+				http.Error(w, "password reset is disabled", http.StatusServiceUnavailable)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// ResetPassword sets new user password by reset token. All user sessions are revoked.
+// 200 — пароль успешно изменён;
+// 400 — неверный формат запроса или новый пароль не соответствует требованиям;
+// 401 — токен сброса недействителен, истёк или уже использован;
+// 500 — внутренняя ошибка сервера.
+func (ph *PasswordHandler) ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var resetReq resetPasswordRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&resetReq); err != nil || resetReq.Token == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		err := ph.passwordSvc.ResetPassword(r.Context(), resetReq.Token, resetReq.NewPassword)
+		if err != nil {
+			var validationErr *models.ValidationError
+			if errors.As(err, &validationErr) {
+				writeValidationError(w, validationErr)
+				return
+			}
+			if errors.Is(err, models.ErrInvalidResetToken) {
+				http.Error(w, "invalid reset token", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
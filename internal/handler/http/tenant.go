@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http"
+)
+
+// requestTenantID returns id of tenant resolved by Tenant middleware
+func requestTenantID(r *http.Request) (uint64, bool) {
+	tenant, ok := r.Context().Value("tenant").(*models.Tenant)
+	if !ok {
+		return 0, false
+	}
+	return tenant.ID, true
+}
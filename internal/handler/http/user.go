@@ -5,9 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/rookgm/gophermart/internal/models"
-	"github.com/rookgm/gophermart/internal/service"
 	"net/http"
-	"time"
 )
 
 // UserService is interface for interfacing with user-related logic
@@ -18,14 +16,15 @@ type UserService interface {
 
 // UserHandler represents HTTP handler for user-related requests
 type UserHandler struct {
-	userSvc  UserService
-	tokenSvc service.TokenService
+	userSvc UserService
+	authSvc AuthService
 }
 
 // NewUserHandler creates new UserHandler instance
-func NewUserHandler(us UserService) *UserHandler {
+func NewUserHandler(us UserService, as AuthService) *UserHandler {
 	return &UserHandler{
 		userSvc: us,
+		authSvc: as,
 	}
 }
 
@@ -37,11 +36,16 @@ type registerRequest struct {
 
 // RegisterUser registers new user
 // 200 — пользователь успешно зарегистрирован и аутентифицирован;
-// 400 — неверный формат запроса;
+// 400 — неверный формат запроса или логин и пароль не соответствуют требованиям;
 // 409 — логин уже занят;
 // 500 — внутренняя ошибка сервера.
 func (uh *UserHandler) RegisterUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := requestTenantID(r)
+		if !ok {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
 
 		var regReq registerRequest
 
@@ -52,12 +56,18 @@ func (uh *UserHandler) RegisterUser() http.HandlerFunc {
 		defer r.Body.Close()
 
 		user := models.User{
+			TenantID: tenantID,
 			Login:    regReq.Login,
 			Password: regReq.Password,
 		}
 
 		_, err := uh.userSvc.Register(r.Context(), &user)
 		if err != nil {
+			var validationErr *models.ValidationError
+			if errors.As(err, &validationErr) {
+				writeValidationError(w, validationErr)
+				return
+			}
 			if errors.Is(err, models.ErrConflictData) {
 				http.Error(w, "bad request", http.StatusConflict)
 				return
@@ -67,20 +77,12 @@ func (uh *UserHandler) RegisterUser() http.HandlerFunc {
 			}
 		}
 
-		token, err := uh.tokenSvc.CreateToken(&user)
+		tokens, err := uh.authSvc.CreateSession(r.Context(), &user)
 		if err != nil {
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "auth_gophermart",
-			Value:    token,
-			Path:     "/",
-			Expires:  time.Now().Add(24 * time.Hour),
-			HttpOnly: true,
-		})
-
-		w.WriteHeader(http.StatusOK)
+		writeTokens(w, tokens)
 	}
 }
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http"
+)
+
+// validationErrorResp is response listing violated validation rules
+type validationErrorResp struct {
+	Error  string              `json:"error"`
+	Fields []models.FieldError `json:"fields"`
+}
+
+// writeValidationError responds with 400 and violated validation rules
+func writeValidationError(w http.ResponseWriter, err *models.ValidationError) {
+	resp, merr := json.Marshal(validationErrorResp{
+		Error:  models.ErrValidation.Error(),
+		Fields: err.Fields,
+	})
+	if merr != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(resp)
+}
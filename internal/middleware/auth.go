@@ -2,28 +2,85 @@ package middleware
 
 import (
 	"context"
+	"github.com/rookgm/gophermart/internal/models"
 	"github.com/rookgm/gophermart/internal/service"
 	"net/http"
+	"strings"
 )
 
-func Auth(ts service.TokenService) func(handler http.Handler) http.Handler {
+const (
+	accessTokenCookie = "auth_token"
+	bearerScheme      = "bearer"
+)
+
+// RevocationChecker is interface for checking token revocation
+type RevocationChecker interface {
+	// IsTokenRevoked checks whether access token has been revoked
+	IsTokenRevoked(ctx context.Context, payload *models.TokenPayload) (bool, error)
+}
+
+// Auth authenticates request by access token given in Authorization header
+// as bearer token or in auth_token cookie. Token issued by another tenant is rejected,
+// so it must be used after Tenant middleware.
+func Auth(ts service.TokenService, rc RevocationChecker) func(handler http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie("auth_token")
+			token, ok := accessToken(r)
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			payload, err := ts.VerifyToken(token)
 			if err != nil {
-				http.Error(w, "can not get cookie", http.StatusInternalServerError)
+				unauthorized(w)
 				return
 			}
 
-			payload, err := ts.VerifyToken(cookie.Value)
+			revoked, err := rc.IsTokenRevoked(r.Context(), payload)
 			if err != nil {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				unauthorized(w)
+				return
+			}
+
+			tenant, ok := r.Context().Value("tenant").(*models.Tenant)
+			if !ok || tenant.ID != payload.TenantID {
+				unauthorized(w)
 				return
 			}
 
 			ctx := context.WithValue(r.Context(), "userid", payload.UserID)
+			ctx = context.WithValue(ctx, "token", payload)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// accessToken returns access token of request. Authorization header takes precedence over cookie.
+func accessToken(r *http.Request) (string, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, bearerScheme) {
+			return "", false
+		}
+		token = strings.TrimSpace(token)
+		return token, token != ""
+	}
+
+	cookie, err := r.Cookie(accessTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// unauthorized responds with 401 and bearer authentication challenge
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/service"
+	"net"
+	"net/http"
+)
+
+// RequestInfo stores client IP, user agent and request id in request context, so they are
+// recorded in audit events and used by login throttle. Request id is taken from X-Request-Id header
+// or generated. Behind reverse proxy RemoteAddr should be set from forwarded headers by trusted middleware.
+func RequestInfo(next http.Handler) http.Handler {
+	return chimw.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		requestID := chimw.GetReqID(r.Context())
+		w.Header().Set(chimw.RequestIDHeader, requestID)
+
+		ctx := service.WithRequestInfo(r.Context(), models.RequestInfo{
+			IP:        ip,
+			UserAgent: r.UserAgent(),
+			RequestID: requestID,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}))
+}
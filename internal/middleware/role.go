@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/rookgm/gophermart/internal/models"
+	"net/http"
+	"slices"
+)
+
+// RequireRole allows request only to users having one of roles.
+// It must be used after Auth middleware.
+func RequireRole(roles ...string) func(handler http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			payload, ok := r.Context().Value("token").(*models.TokenPayload)
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			if !slices.Contains(roles, payload.Role) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
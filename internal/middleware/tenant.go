@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"net"
+	"net/http"
+)
+
+// tenantHeader is header selecting tenant, it takes precedence over host
+const tenantHeader = "X-Tenant"
+
+// TenantResolver is interface for resolving tenant of request
+type TenantResolver interface {
+	// Resolve returns tenant by slug if it is set, otherwise by host
+	Resolve(ctx context.Context, slug string, host string) (*models.Tenant, error)
+}
+
+// Tenant resolves tenant of request by X-Tenant header or host and stores it in request context
+func Tenant(tr TenantResolver) func(handler http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.Host)
+			if err != nil {
+				host = r.Host
+			}
+
+			tenant, err := tr.Resolve(r.Context(), r.Header.Get(tenantHeader), host)
+			if err != nil {
+				if errors.Is(err, models.ErrDataNotFound) {
+					http.Error(w, "unknown tenant", http.StatusNotFound)
+					return
+				}
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "tenant", tenant)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+//REGISTERED — заказ зарегистрирован, но начисление не рассчитано;
+//INVALID — заказ не принят к расчёту, и вознаграждение не будет начислено;
+//PROCESSING — расчёт начисления в процессе;
+//PROCESSED — расчёт начисления окончен.
+
+// accrual system order status
+const (
+	AccrualStatusRegistered = "REGISTERED"
+	AccrualStatusInvalid    = "INVALID"
+	AccrualStatusProcessing = "PROCESSING"
+	AccrualStatusProcessed  = "PROCESSED"
+)
+
+// Accrual is order accrual calculated by accrual system
+type Accrual struct {
+	Order   string
+	Status  string
+	Accrual *float64
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// audit event types
+const (
+	AuditUserRegistered     = "user.registered"
+	AuditPasswordChanged    = "user.password_changed"
+	AuditPasswordReset      = "user.password_reset"
+	AuditLoginSucceeded     = "auth.login_succeeded"
+	AuditLoginFailed        = "auth.login_failed"
+	AuditLoginLocked        = "auth.login_locked"
+	AuditLogout             = "auth.logout"
+	AuditTokenFamilyRevoked = "auth.token_family_revoked"
+	AuditOrderUploaded      = "order.uploaded"
+	AuditOrderStatusChanged = "order.status_changed"
+	AuditOrderRequeued      = "order.requeued"
+	AuditWithdrawalCreated  = "balance.withdrawn"
+	AuditBalanceAdjusted    = "balance.adjusted"
+)
+
+// AuditEvent is record of security or financial event. Actor is user who performed action,
+// user is user affected by it. Events of background jobs have no actor.
+type AuditEvent struct {
+	ID uint64
+	// TenantID is tenant event happened in, it is set even if event affects no known user
+	TenantID  uint64
+	Type      string
+	ActorID   *uint64
+	UserID    *uint64
+	IP        string
+	UserAgent string
+	RequestID string
+	Details   map[string]interface{}
+	CreatedAt time.Time
+}
+
+// AuditFilter selects audit events of tenant. Zero values of other fields do not filter.
+type AuditFilter struct {
+	TenantID uint64
+	// Before selects events with id less than Before, used for paging
+	Before uint64
+	Type   string
+	// UserID selects events performed by or affecting user
+	UserID uint64
+}
+
+// RequestInfo is client request metadata recorded in audit events
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
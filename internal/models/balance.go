@@ -0,0 +1,10 @@
+package models
+
+// Balance is user loyalty points balance
+type Balance struct {
+	UserID    uint64
+	Current   float64
+	Withdrawn float64
+	// ExpiringSoon is amount of current points which expire soon
+	ExpiringSoon float64
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// bonus rule kinds
+const (
+	// BonusRuleSignup grants fixed amount of points on registration
+	BonusRuleSignup = "signup"
+	// BonusRuleFirstOrder grants bonus for the first processed user order
+	BonusRuleFirstOrder = "first_order"
+	// BonusRulePeriod grants bonus for orders uploaded during rule period
+	BonusRulePeriod = "period"
+)
+
+// BonusRule is promotional credit rule. Order bonus is accrual multiplied by
+// Multiplier - 1 plus fixed Amount, so Multiplier 2 doubles accrual points.
+type BonusRule struct {
+	ID         uint64
+	Name       string
+	Kind       string
+	Amount     float64
+	Multiplier float64
+	StartsAt   *time.Time
+	EndsAt     *time.Time
+	Active     bool
+	CreatedAt  time.Time
+}
+
+// Bonus is bonus credited to user by rule
+type Bonus struct {
+	RuleID    uint64
+	RuleName  string
+	Order     *string
+	Amount    float64
+	CreatedAt time.Time
+}
@@ -9,4 +9,11 @@ var (
 	ErrInvalidOrderID         = errors.New("invalid order id")
 	ErrOrderLoadedUser        = errors.New("order already loaded by user")
 	ErrOrderLoadedAnotherUser = errors.New("order already loaded by another user")
+	ErrInsufficientFunds      = errors.New("insufficient funds")
+	ErrInvalidRefreshToken    = errors.New("invalid refresh token")
+	ErrLoginLocked            = errors.New("too many failed login attempts")
+	ErrValidation             = errors.New("validation failed")
+	ErrInvalidResetToken      = errors.New("invalid password reset token")
+	ErrOrderProcessed         = errors.New("order has already been processed")
+	ErrPasswordResetDisabled  = errors.New("password reset is disabled")
 )
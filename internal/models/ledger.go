@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// ledger accounts
+const (
+	// LedgerAccountUser is user points account
+	LedgerAccountUser = "user"
+	// LedgerAccountAccrual is account of points issued by accrual system
+	LedgerAccountAccrual = "accrual"
+	// LedgerAccountWithdrawal is account of points withdrawn by users
+	LedgerAccountWithdrawal = "withdrawal"
+	// LedgerAccountBonus is account of points granted by bonus rules
+	LedgerAccountBonus = "bonus"
+	// LedgerAccountExpired is account of expired points
+	LedgerAccountExpired = "expired"
+	// LedgerAccountAdjustment is account of points adjusted manually by back-office staff
+	LedgerAccountAdjustment = "adjustment"
+)
+
+// LedgerEntry is points ledger entry. Each entry moves amount of points
+// from debit account to credit account, so the ledger is always balanced.
+type LedgerEntry struct {
+	ID            uint64
+	UserID        uint64
+	DebitAccount  string
+	CreditAccount string
+	Amount        float64
+	OrderID       *uint64
+	WithdrawalID  *uint64
+	RuleID        *uint64
+	ExpiresAt     *time.Time
+	ReversalOf    *uint64
+	CreatedAt     time.Time
+}
+
+// CreditLot is credit to user account with points which have not been consumed yet
+type CreditLot struct {
+	EntryID   uint64
+	UserID    uint64
+	Amount    float64
+	Remaining float64
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
+
+// LedgerAllocation is part of credit consumed by debit of user account
+type LedgerAllocation struct {
+	ID            uint64
+	DebitEntryID  uint64
+	CreditEntryID uint64
+	Amount        float64
+	CreatedAt     time.Time
+}
+
+// Adjustment is manual balance adjustment. Positive amount credits user account,
+// negative amount debits it. Actor is back-office user who made adjustment.
+type Adjustment struct {
+	ID        uint64
+	UserID    uint64
+	ActorID   uint64
+	Amount    float64
+	Reason    string
+	EntryID   uint64
+	CreatedAt time.Time
+}
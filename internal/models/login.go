@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginAttempts is failed login attempts counted by throttle key, e.g. login or client IP
+type LoginAttempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// LoginLockout is audit record of throttle key lockout
+type LoginLockout struct {
+	ID          uint64
+	Key         string
+	IP          string
+	Failures    int
+	LockedUntil time.Time
+	CreatedAt   time.Time
+}
+
+// LockoutError is returned when login is temporarily locked out
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", ErrLoginLocked, e.RetryAfter)
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrLoginLocked
+}
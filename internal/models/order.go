@@ -15,12 +15,53 @@ const (
 	OrderStatusProcessed  = "PROCESSED"
 )
 
+// results of order upload
+const (
+	OrderUploadAccepted = "accepted"
+	OrderUploadExisting = "already_uploaded"
+	OrderUploadConflict = "conflict"
+	OrderUploadInvalid  = "invalid"
+)
+
+// OrderUpload is result of uploading order number within batch
+type OrderUpload struct {
+	Number string
+	Result string
+}
+
+// OrderCursor is position in list of orders sorted by upload time and id, newest first
+type OrderCursor struct {
+	UploadedAt time.Time
+	ID         uint64
+}
+
+// OrderFilter selects user orders. Zero values do not filter.
+type OrderFilter struct {
+	Statuses []string
+	// From and To limit upload time, From is inclusive and To is exclusive
+	From *time.Time
+	To   *time.Time
+	// After selects orders following cursor
+	After *OrderCursor
+}
+
+// OrderPage is page of orders. Next is cursor of the next page, nil if page is the last one.
+type OrderPage struct {
+	Orders []Order
+	Next   *OrderCursor
+}
+
 // Order is order entity
 type Order struct {
 	ID         uint64
+	TenantID   uint64
 	UserID     uint64
 	Number     string
 	Status     string
 	Accrual    *float64
 	UploadedAt time.Time
+	// Attempts is number of accrual checks which did not change order, it is set for accrual queue only
+	Attempts int
+	// NotRegistered is number of consecutive checks accrual system did not know order at
+	NotRegistered int
 }
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// DefaultTenantID is id of tenant serving requests not addressed to any other tenant.
+// Data created before tenants were introduced belongs to it.
+const DefaultTenantID uint64 = 1
+
+// Tenant is storefront served by gophermart. Users and orders of tenants are isolated,
+// so the same login or order number may be registered by different tenants.
+type Tenant struct {
+	ID   uint64
+	Slug string
+	Name string
+	// Host is host name of storefront, empty if tenant is resolved by header only
+	Host string
+	// AccrualAddress is address of tenant accrual system, empty if default accrual system is used
+	AccrualAddress string
+	// OrderFormat is format of tenant order numbers
+	OrderFormat OrderNumberFormat
+	CreatedAt   time.Time
+}
+
+// order number checksum algorithms
+const (
+	ChecksumLuhn = "luhn"
+	ChecksumNone = "none"
+)
+
+// OrderNumberFormat is format of order numbers. Zero lengths do not limit length.
+type OrderNumberFormat struct {
+	// Prefix is digits order number starts with
+	Prefix    string
+	MinLength int
+	MaxLength int
+	// Checksum is checksum algorithm of order number
+	Checksum string
+}
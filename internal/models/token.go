@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RefreshToken is refresh token entity. Only token hash is stored.
+// Tokens rotated from the same login share family id.
+type RefreshToken struct {
+	ID        uint64
+	UserID    uint64
+	FamilyID  uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// TokenPair is access and refresh tokens issued to user
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// PasswordResetToken is one-time password reset token entity. Only token hash is stored.
+type PasswordResetToken struct {
+	ID        uint64
+	UserID    uint64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
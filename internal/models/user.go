@@ -5,16 +5,29 @@ import (
 	"time"
 )
 
+// user roles
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
 // User is user entity
 type User struct {
 	ID        uint64
+	TenantID  uint64
 	Login     string
 	Password  string
+	Role      string
 	CreatedAt time.Time
 }
 
 // TokenPayload is payload contains user id's
 type TokenPayload struct {
-	ID     uuid.UUID
-	UserID uint64
+	ID        uuid.UUID
+	UserID    uint64
+	TenantID  uint64
+	Role      string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Withdrawal is loyalty points withdrawal entity
+type Withdrawal struct {
+	ID          uint64
+	UserID      uint64
+	Order       string
+	Sum         float64
+	ProcessedAt time.Time
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/rookgm/gophermart/internal/models"
+	"go.uber.org/zap"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogNotifier writes password reset tokens to log. It is intended for local use only.
+type LogNotifier struct {
+	logger *zap.Logger
+}
+
+// NewLogNotifier creates new LogNotifier instance
+func NewLogNotifier(logger *zap.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+// NotifyPasswordReset logs password reset token of user
+func (ln *LogNotifier) NotifyPasswordReset(_ context.Context, user *models.User, token string, expiresAt time.Time) error {
+	ln.logger.Info("Password reset requested",
+		zap.String("login", user.Login),
+		zap.String("token", token),
+		zap.Time("expires_at", expiresAt),
+	)
+	return nil
+}
+
+// FileNotifier appends password reset tokens to file as JSON lines
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier creates new FileNotifier instance
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+// resetMessage is password reset message written to file
+type resetMessage struct {
+	Login     string    `json:"login"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NotifyPasswordReset appends password reset token of user to file readable by owner only
+func (fn *FileNotifier) NotifyPasswordReset(_ context.Context, user *models.User, token string, expiresAt time.Time) error {
+	data, err := json.Marshal(resetMessage{Login: user.Login, Token: token, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	f, err := os.OpenFile(fn.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
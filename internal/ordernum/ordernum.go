@@ -0,0 +1,112 @@
+package ordernum
+
+import (
+	"context"
+	"fmt"
+	"github.com/rookgm/gophermart/internal/models"
+	"strings"
+)
+
+// DefaultFormat accepts digit strings of any length passing Luhn check
+var DefaultFormat = models.OrderNumberFormat{Checksum: models.ChecksumLuhn}
+
+// TenantLister is interface for listing tenants
+type TenantLister interface {
+	// ListTenants returns all tenants
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+}
+
+// Validator validates order numbers by formats of tenants.
+// Tenants without own format use DefaultFormat.
+type Validator struct {
+	tenants TenantLister
+}
+
+// NewValidator creates new Validator instance
+func NewValidator(tenants TenantLister) *Validator {
+	return &Validator{tenants: tenants}
+}
+
+// Validate checks order number of tenant and returns it without surrounding whitespace.
+// Returns error wrapping ErrInvalidOrderID if number does not match tenant format.
+func (v *Validator) Validate(ctx context.Context, tenantID uint64, number string) (string, error) {
+	tenants, err := v.tenants.ListTenants(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	format := DefaultFormat
+	for _, tenant := range tenants {
+		if tenant.ID == tenantID {
+			format = tenant.OrderFormat
+			break
+		}
+	}
+
+	return Check(format, number)
+}
+
+// Check checks order number by format and returns it without surrounding whitespace
+func Check(format models.OrderNumberFormat, number string) (string, error) {
+	number = strings.TrimSpace(number)
+
+	if number == "" {
+		return "", fmt.Errorf("%w: empty number", models.ErrInvalidOrderID)
+	}
+
+	for i := 0; i < len(number); i++ {
+		if number[i] < '0' || number[i] > '9' {
+			return "", fmt.Errorf("%w: non-digit character", models.ErrInvalidOrderID)
+		}
+	}
+
+	if !strings.HasPrefix(number, format.Prefix) {
+		return "", fmt.Errorf("%w: prefix must be %s", models.ErrInvalidOrderID, format.Prefix)
+	}
+
+	if format.MinLength > 0 && len(number) < format.MinLength {
+		return "", fmt.Errorf("%w: shorter than %d digits", models.ErrInvalidOrderID, format.MinLength)
+	}
+	if format.MaxLength > 0 && len(number) > format.MaxLength {
+		return "", fmt.Errorf("%w: longer than %d digits", models.ErrInvalidOrderID, format.MaxLength)
+	}
+
+	switch format.Checksum {
+	case models.ChecksumLuhn:
+		if !Luhn(number) {
+			return "", fmt.Errorf("%w: checksum mismatch", models.ErrInvalidOrderID)
+		}
+	case models.ChecksumNone:
+	default:
+		return "", fmt.Errorf("unknown order number checksum %q", format.Checksum)
+	}
+
+	return number, nil
+}
+
+// Luhn checks digit string of any length using Luhn algorithm
+func Luhn(number string) bool {
+	if number == "" {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			return false
+		}
+
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
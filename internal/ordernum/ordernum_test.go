@@ -0,0 +1,165 @@
+package ordernum
+
+import (
+	"context"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"testing"
+)
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{number: "0", want: true},
+		{number: "18", want: true},
+		{number: "79927398713", want: true},
+		{number: "79927398710", want: false},
+		{number: "4561261212345467", want: true},
+		{number: "4561261212345464", want: false},
+		{number: "12345678903", want: true},
+		{number: "123456789012345678901234567890123456789012345678905", want: true},
+		{number: "", want: false},
+		{number: "1234a", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			if got := Luhn(tt.number); got != tt.want {
+				t.Errorf("Luhn(%q) = %v, want %v", tt.number, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  models.OrderNumberFormat
+		number  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "default format",
+			format: DefaultFormat,
+			number: "12345678903",
+			want:   "12345678903",
+		},
+		{
+			name:   "surrounding whitespace is trimmed",
+			format: DefaultFormat,
+			number: " 12345678903\n",
+			want:   "12345678903",
+		},
+		{
+			name:    "empty number",
+			format:  DefaultFormat,
+			number:  "  ",
+			wantErr: models.ErrInvalidOrderID,
+		},
+		{
+			name:    "non-digit character",
+			format:  DefaultFormat,
+			number:  "1234-5678903",
+			wantErr: models.ErrInvalidOrderID,
+		},
+		{
+			name:    "checksum mismatch",
+			format:  DefaultFormat,
+			number:  "12345678901",
+			wantErr: models.ErrInvalidOrderID,
+		},
+		{
+			name:   "no checksum",
+			format: models.OrderNumberFormat{Checksum: models.ChecksumNone},
+			number: "12345678901",
+			want:   "12345678901",
+		},
+		{
+			name:   "prefix matches",
+			format: models.OrderNumberFormat{Prefix: "77", Checksum: models.ChecksumNone},
+			number: "7712",
+			want:   "7712",
+		},
+		{
+			name:    "prefix mismatch",
+			format:  models.OrderNumberFormat{Prefix: "77", Checksum: models.ChecksumNone},
+			number:  "7812",
+			wantErr: models.ErrInvalidOrderID,
+		},
+		{
+			name:    "shorter than min length",
+			format:  models.OrderNumberFormat{MinLength: 5, Checksum: models.ChecksumNone},
+			number:  "1234",
+			wantErr: models.ErrInvalidOrderID,
+		},
+		{
+			name:    "longer than max length",
+			format:  models.OrderNumberFormat{MaxLength: 3, Checksum: models.ChecksumNone},
+			number:  "1234",
+			wantErr: models.ErrInvalidOrderID,
+		},
+		{
+			name:   "length within bounds",
+			format: models.OrderNumberFormat{MinLength: 4, MaxLength: 4, Checksum: models.ChecksumNone},
+			number: "1234",
+			want:   "1234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Check(tt.format, tt.number)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckUnknownChecksum(t *testing.T) {
+	_, err := Check(models.OrderNumberFormat{Checksum: "crc"}, "1234")
+	if err == nil || errors.Is(err, models.ErrInvalidOrderID) {
+		t.Errorf("Check() error = %v, want configuration error", err)
+	}
+}
+
+// tenantList is fixed list of tenants
+type tenantList []models.Tenant
+
+func (tl tenantList) ListTenants(context.Context) ([]models.Tenant, error) {
+	return tl, nil
+}
+
+func TestValidatorValidate(t *testing.T) {
+	validator := NewValidator(tenantList{
+		{ID: 1, OrderFormat: DefaultFormat},
+		{ID: 2, OrderFormat: models.OrderNumberFormat{Prefix: "9", MaxLength: 6, Checksum: models.ChecksumNone}},
+	})
+
+	tests := []struct {
+		name     string
+		tenantID uint64
+		number   string
+		wantErr  error
+	}{
+		{name: "default tenant", tenantID: 1, number: "12345678903"},
+		{name: "default tenant checksum mismatch", tenantID: 1, number: "12345678901", wantErr: models.ErrInvalidOrderID},
+		{name: "tenant format", tenantID: 2, number: "912340"},
+		{name: "tenant format too long", tenantID: 2, number: "9123456", wantErr: models.ErrInvalidOrderID},
+		{name: "unknown tenant uses default format", tenantID: 3, number: "912340", wantErr: models.ErrInvalidOrderID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := validator.Validate(context.Background(), tt.tenantID, tt.number); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// password hashing algorithms
+const (
+	AlgBcrypt   = "bcrypt"
+	AlgArgon2id = "argon2id"
+)
+
+// argon2idPrefix is prefix of argon2id hash in PHC string format
+const argon2idPrefix = "$argon2id$"
+
+// maximum password lengths in bytes accepted by algorithms
+const (
+	// bcryptMaxBytes is limit of bcrypt, longer passwords are not hashed
+	bcryptMaxBytes = 72
+	// argon2idMaxBytes only bounds work per request, argon2id hashes passwords of any length
+	argon2idMaxBytes = 1024
+)
+
+var (
+	ErrMismatch    = errors.New("password does not match hash")
+	ErrUnknownHash = errors.New("unknown password hash format")
+	ErrAlgorithm   = errors.New("unsupported password hash algorithm")
+)
+
+// Argon2Params is argon2id parameters. Memory is in KiB.
+type Argon2Params struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+// DefaultArgon2Params is argon2id parameters recommended by RFC 9106 for memory constrained environments
+var DefaultArgon2Params = Argon2Params{
+	Memory:  64 * 1024,
+	Time:    3,
+	Threads: 4,
+	SaltLen: 16,
+	KeyLen:  32,
+}
+
+// Hasher hashes passwords by configured algorithm. Hashes describe their algorithm
+// and parameters, so hashes created by other algorithms or parameters are still verified.
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     Argon2Params
+}
+
+// NewHasher creates Hasher hashing passwords by algorithm with given bcrypt cost or argon2id parameters
+func NewHasher(algorithm string, bcryptCost int, argon2 Argon2Params) (*Hasher, error) {
+	switch algorithm {
+	case AlgBcrypt:
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return nil, bcrypt.InvalidCostError(bcryptCost)
+		}
+	case AlgArgon2id:
+	default:
+		return nil, ErrAlgorithm
+	}
+
+	return &Hasher{algorithm: algorithm, bcryptCost: bcryptCost, argon2: argon2}, nil
+}
+
+// MaxBytes returns maximum password length in bytes accepted by configured algorithm
+func (h *Hasher) MaxBytes() int {
+	if h.algorithm == AlgArgon2id {
+		return argon2idMaxBytes
+	}
+	return bcryptMaxBytes
+}
+
+// Hash returns hash of password
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.algorithm == AlgArgon2id {
+		return h.hashArgon2id(password)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify checks whether password matches hash. Returns ErrMismatch if it does not.
+func (h *Hasher) Verify(password string, hash string) error {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		params, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return err
+		}
+
+		computed := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	}
+
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return ErrUnknownHash
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return err
+	}
+	return nil
+}
+
+// NeedsRehash checks whether hash was created by another algorithm or weaker parameters than configured
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		if h.algorithm != AlgArgon2id {
+			return true
+		}
+
+		params, _, _, err := parseArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		return params.Memory < h.argon2.Memory ||
+			params.Time < h.argon2.Time ||
+			params.Threads < h.argon2.Threads ||
+			params.KeyLen < h.argon2.KeyLen
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		// hash can not be verified, so it can not be rehashed either
+		return false
+	}
+	return h.algorithm != AlgBcrypt || cost < h.bcryptCost
+}
+
+// hashArgon2id returns argon2id hash of password in PHC string format:
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>
+func (h *Hasher) hashArgon2id(password string) (string, error) {
+	salt := make([]byte, h.argon2.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.argon2.Time, h.argon2.Memory, h.argon2.Threads, h.argon2.KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, h.argon2.Memory, h.argon2.Time, h.argon2.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// parseArgon2id parses argon2id hash in PHC string format
+func parseArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	params.SaltLen = uint32(len(salt))
+	params.KeyLen = uint32(len(key))
+
+	return params, salt, key, nil
+}
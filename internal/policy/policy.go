@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"github.com/rookgm/gophermart/internal/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// validation rule codes
+const (
+	CodeRequired    = "required"
+	CodeTooShort    = "too_short"
+	CodeTooLong     = "too_long"
+	CodeCharset     = "invalid_characters"
+	CodeComplexity  = "too_simple"
+	CodeCommon      = "too_common"
+	CodeSameAsLogin = "same_as_login"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords is set of bundled common passwords in lower case
+var commonPasswords = parseCommonPasswords(commonPasswordsFile)
+
+// Policy is login and password validation rules
+type Policy struct {
+	// LoginMinLength and LoginMaxLength limit login length in characters
+	LoginMinLength int
+	LoginMaxLength int
+	// PasswordMinLength is minimum password length in characters
+	PasswordMinLength int
+	// PasswordMaxBytes is maximum password length in bytes accepted by password hashing algorithm
+	PasswordMaxBytes int
+	// PasswordMinClasses is minimum number of character classes used in password:
+	// lower case letters, upper case letters, digits and other characters
+	PasswordMinClasses int
+}
+
+// New creates Policy with default login rules and given password rules
+func New(passwordMinLength int, passwordMaxBytes int, passwordMinClasses int) *Policy {
+	return &Policy{
+		LoginMinLength:     3,
+		LoginMaxLength:     64,
+		PasswordMinLength:  passwordMinLength,
+		PasswordMaxBytes:   passwordMaxBytes,
+		PasswordMinClasses: passwordMinClasses,
+	}
+}
+
+// Validate validates credentials of new user. Returns ValidationError listing all violations.
+func (p *Policy) Validate(login string, password string) error {
+	fields := append(p.validateLogin(login), p.validatePassword(password, login)...)
+	if len(fields) > 0 {
+		return &models.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// ValidatePassword validates new password of user. Returns ValidationError listing all violations.
+func (p *Policy) ValidatePassword(password string, login string) error {
+	if fields := p.validatePassword(password, login); len(fields) > 0 {
+		return &models.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// validateLogin checks login length and charset. Login may contain latin letters, digits and ._@- characters.
+func (p *Policy) validateLogin(login string) []models.FieldError {
+	if login == "" {
+		return []models.FieldError{loginError(CodeRequired, "login is required")}
+	}
+
+	var fields []models.FieldError
+
+	length := utf8.RuneCountInString(login)
+	if length < p.LoginMinLength {
+		fields = append(fields, loginError(CodeTooShort, fmt.Sprintf("login must be at least %d characters", p.LoginMinLength)))
+	}
+	if length > p.LoginMaxLength {
+		fields = append(fields, loginError(CodeTooLong, fmt.Sprintf("login must be at most %d characters", p.LoginMaxLength)))
+	}
+
+	for _, r := range login {
+		if !isLoginRune(r) {
+			fields = append(fields, loginError(CodeCharset, "login may contain only latin letters, digits and . _ @ - characters"))
+			break
+		}
+	}
+
+	return fields
+}
+
+// validatePassword checks password length, complexity and that it is not common or same as login
+func (p *Policy) validatePassword(password string, login string) []models.FieldError {
+	if password == "" {
+		return []models.FieldError{passwordError(CodeRequired, "password is required")}
+	}
+
+	var fields []models.FieldError
+
+	if utf8.RuneCountInString(password) < p.PasswordMinLength {
+		fields = append(fields, passwordError(CodeTooShort, fmt.Sprintf("password must be at least %d characters", p.PasswordMinLength)))
+	}
+	// hashing algorithm does not hash passwords beyond its limit, so they are rejected here
+	// with validation error rather than failing in hashing
+	if len(password) > p.PasswordMaxBytes {
+		fields = append(fields, passwordError(CodeTooLong, fmt.Sprintf("password must be at most %d bytes", p.PasswordMaxBytes)))
+	}
+
+	if characterClasses(password) < p.PasswordMinClasses {
+		fields = append(fields, passwordError(CodeComplexity, fmt.Sprintf(
+			"password must contain at least %d of: lower case letters, upper case letters, digits, other characters",
+			p.PasswordMinClasses)))
+	}
+
+	lower := strings.ToLower(password)
+	if _, ok := commonPasswords[lower]; ok {
+		fields = append(fields, passwordError(CodeCommon, "password is too common"))
+	}
+	if login != "" && lower == strings.ToLower(login) {
+		fields = append(fields, passwordError(CodeSameAsLogin, "password must differ from login"))
+	}
+
+	return fields
+}
+
+// characterClasses returns number of character classes used in s
+func characterClasses(s string) int {
+	var lower, upper, digit, other int
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
+
+// isLoginRune checks whether r is allowed in login
+func isLoginRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r == '.', r == '_', r == '@', r == '-':
+		return true
+	}
+	return false
+}
+
+func loginError(code string, message string) models.FieldError {
+	return models.FieldError{Field: "login", Code: code, Message: message}
+}
+
+func passwordError(code string, message string) models.FieldError {
+	return models.FieldError{Field: "password", Code: code, Message: message}
+}
+
+// parseCommonPasswords parses common passwords file skipping empty lines and comments
+func parseCommonPasswords(file string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(file))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+)
+
+const (
+	insertAuditEventQuery = `
+						INSERT INTO audit_events (tenant_id, type, actor_id, user_id, ip, user_agent, request_id, details)
+						values ($1, $2, $3, $4, $5, $6, $7, $8)
+						RETURNING id, created_at;
+`
+
+	// selectAuditEventsQuery selects events of tenant newest first, zero filter values do not filter
+	selectAuditEventsQuery = `
+						SELECT id, tenant_id, type, actor_id, user_id, ip, user_agent, request_id, details, created_at FROM audit_events
+						WHERE tenant_id = $1
+							AND ($2::bigint = 0 OR id < $2)
+							AND ($3::varchar = '' OR type = $3)
+							AND ($4::bigint = 0 OR user_id = $4 OR actor_id = $4)
+						ORDER BY id DESC
+						LIMIT $5
+`
+)
+
+// AuditRepository implements audit repository interface
+type AuditRepository struct {
+	db postgres.Querier
+}
+
+// NewAuditRepository creates new AuditRepository instance
+func NewAuditRepository(db postgres.Querier) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// CreateEvent appends event to audit log
+func (ar *AuditRepository) CreateEvent(ctx context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+	details := event.Details
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+
+	err := ar.db.QueryRow(ctx, insertAuditEventQuery,
+		event.TenantID, event.Type, event.ActorID, event.UserID, event.IP, event.UserAgent, event.RequestID, details).
+		Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// GetEvents returns events matching filter, newest first
+func (ar *AuditRepository) GetEvents(ctx context.Context, filter models.AuditFilter, limit int) ([]models.AuditEvent, error) {
+	rows, err := ar.db.Query(ctx, selectAuditEventsQuery, filter.TenantID, filter.Before, filter.Type, filter.UserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+
+	for rows.Next() {
+		event := models.AuditEvent{}
+		err := rows.Scan(&event.ID, &event.TenantID, &event.Type, &event.ActorID, &event.UserID, &event.IP, &event.UserAgent,
+			&event.RequestID, &event.Details, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+	"time"
+)
+
+const (
+	selectActiveBonusRulesQuery = `
+						SELECT id, name, kind, amount, multiplier, starts_at, ends_at, active, created_at FROM bonus_rules
+						WHERE active AND kind = ANY($1)
+							AND (starts_at IS NULL OR starts_at <= $2)
+							AND (ends_at IS NULL OR ends_at > $2)
+						ORDER BY id
+`
+)
+
+// BonusRuleRepository implements bonus rule repository interface
+type BonusRuleRepository struct {
+	db postgres.Querier
+}
+
+// NewBonusRuleRepository creates new BonusRuleRepository instance
+func NewBonusRuleRepository(db postgres.Querier) *BonusRuleRepository {
+	return &BonusRuleRepository{db: db}
+}
+
+// GetActiveRules returns rules of kinds which are active at time
+func (br *BonusRuleRepository) GetActiveRules(ctx context.Context, kinds []string, at time.Time) ([]models.BonusRule, error) {
+	rows, err := br.db.Query(ctx, selectActiveBonusRulesQuery, kinds, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.BonusRule{}
+
+	for rows.Next() {
+		rule := models.BonusRule{}
+		err = rows.Scan(&rule.ID, &rule.Name, &rule.Kind, &rule.Amount, &rule.Multiplier, &rule.StartsAt, &rule.EndsAt, &rule.Active, &rule.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
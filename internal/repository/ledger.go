@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+	"time"
+)
+
+const (
+	insertLedgerAdjustmentQuery = `
+						INSERT INTO ledger_adjustments (user_id, actor_id, amount, reason, entry_id)
+						values ($1, $2, $3, $4, $5)
+						RETURNING id, user_id, actor_id, amount, reason, entry_id, created_at;
+`
+
+	insertLedgerEntryQuery = `
+						INSERT INTO ledger_entries (user_id, debit_account, credit_account, amount, order_id, withdrawal_id, rule_id, expires_at)
+						values ($1, $2, $3, $4, $5, $6, $7, $8)
+						RETURNING id, user_id, debit_account, credit_account, amount, order_id, withdrawal_id, rule_id, expires_at, reversal_of, created_at;
+`
+
+	// selectBonusesByUserIDQuery selects bonus credits which have not been reversed, newest first
+	selectBonusesByUserIDQuery = `
+						SELECT le.rule_id, br.name, o.number, le.amount, le.created_at FROM ledger_entries le
+						JOIN bonus_rules br ON br.id = le.rule_id
+						LEFT JOIN orders o ON o.id = le.order_id
+						WHERE le.user_id = $1 AND le.reversal_of IS NULL
+							AND NOT EXISTS (SELECT 1 FROM ledger_entries r WHERE r.reversal_of = le.id)
+						ORDER BY le.created_at DESC
+`
+
+	// selectLedgerBalanceQuery calculates points on user and withdrawal accounts of user
+	selectLedgerBalanceQuery = `
+						SELECT
+							COALESCE(SUM(amount) FILTER (WHERE credit_account = $2), 0) - COALESCE(SUM(amount) FILTER (WHERE debit_account = $2), 0),
+							COALESCE(SUM(amount) FILTER (WHERE credit_account = $3), 0) - COALESCE(SUM(amount) FILTER (WHERE debit_account = $3), 0)
+						FROM ledger_entries
+						WHERE user_id = $1
+`
+
+	// selectOpenCreditLotsQuery selects not reversed and not expired credits of user account with unconsumed points
+	selectOpenCreditLotsQuery = `
+						SELECT le.id, le.user_id, le.amount, le.amount - COALESCE(SUM(la.amount), 0), le.expires_at, le.created_at FROM ledger_entries le
+						LEFT JOIN ledger_allocations la ON la.credit_entry_id = le.id
+						WHERE le.user_id = $1 AND le.credit_account = $2
+							AND (le.expires_at IS NULL OR le.expires_at > $3)
+							AND NOT EXISTS (SELECT 1 FROM ledger_entries r WHERE r.reversal_of = le.id)
+						GROUP BY le.id
+						HAVING le.amount - COALESCE(SUM(la.amount), 0) > 0
+						ORDER BY le.created_at, le.id
+`
+
+	// selectExpiredCreditLotsQuery selects not reversed and expired credits of user account with unconsumed points
+	selectExpiredCreditLotsQuery = `
+						SELECT le.id, le.user_id, le.amount, le.amount - COALESCE(SUM(la.amount), 0), le.expires_at, le.created_at FROM ledger_entries le
+						LEFT JOIN ledger_allocations la ON la.credit_entry_id = le.id
+						WHERE le.user_id = $1 AND le.credit_account = $2
+							AND le.expires_at <= $3
+							AND NOT EXISTS (SELECT 1 FROM ledger_entries r WHERE r.reversal_of = le.id)
+						GROUP BY le.id
+						HAVING le.amount - COALESCE(SUM(la.amount), 0) > 0
+						ORDER BY le.created_at, le.id
+`
+
+	selectUsersWithExpiredCreditsQuery = `
+						SELECT DISTINCT le.user_id FROM ledger_entries le
+						WHERE le.credit_account = $1 AND le.expires_at <= $2
+							AND NOT EXISTS (SELECT 1 FROM ledger_entries r WHERE r.reversal_of = le.id)
+							AND le.amount > (SELECT COALESCE(SUM(la.amount), 0) FROM ledger_allocations la WHERE la.credit_entry_id = le.id)
+						LIMIT $3
+`
+
+	insertLedgerAllocationQuery = `
+						INSERT INTO ledger_allocations (debit_entry_id, credit_entry_id, amount)
+						values ($1, $2, $3)
+						RETURNING id, debit_entry_id, credit_entry_id, amount, created_at;
+`
+)
+
+// LedgerRepository implements ledger repository interface
+type LedgerRepository struct {
+	db postgres.Querier
+}
+
+// NewLedgerRepository creates new LedgerRepository instance
+func NewLedgerRepository(db postgres.Querier) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// CreateEntry appends new entry to ledger
+func (lr *LedgerRepository) CreateEntry(ctx context.Context, entry *models.LedgerEntry) (*models.LedgerEntry, error) {
+	err := lr.db.QueryRow(ctx, insertLedgerEntryQuery, entry.UserID, entry.DebitAccount, entry.CreditAccount, entry.Amount, entry.OrderID, entry.WithdrawalID, entry.RuleID, entry.ExpiresAt).
+		Scan(&entry.ID, &entry.UserID, &entry.DebitAccount, &entry.CreditAccount, &entry.Amount, &entry.OrderID, &entry.WithdrawalID, &entry.RuleID, &entry.ExpiresAt, &entry.ReversalOf, &entry.CreatedAt)
+	if err != nil {
+		if errCode := postgres.ErrorCode(err); errCode == "23505" {
+			return nil, models.ErrConflictData
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetBalanceByUserID returns user balance calculated from ledger entries
+func (lr *LedgerRepository) GetBalanceByUserID(ctx context.Context, userID uint64) (*models.Balance, error) {
+	balance := models.Balance{UserID: userID}
+	err := lr.db.QueryRow(ctx, selectLedgerBalanceQuery, userID, models.LedgerAccountUser, models.LedgerAccountWithdrawal).
+		Scan(&balance.Current, &balance.Withdrawn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+// GetBonusesByUserID returns bonuses credited to user, newest first
+func (lr *LedgerRepository) GetBonusesByUserID(ctx context.Context, userID uint64) ([]models.Bonus, error) {
+	rows, err := lr.db.Query(ctx, selectBonusesByUserIDQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bonuses := []models.Bonus{}
+
+	for rows.Next() {
+		bonus := models.Bonus{}
+		err = rows.Scan(&bonus.RuleID, &bonus.RuleName, &bonus.Order, &bonus.Amount, &bonus.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		bonuses = append(bonuses, bonus)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bonuses, nil
+}
+
+// GetOpenCreditLots returns user credits which have not expired at time and have unconsumed points, oldest first
+func (lr *LedgerRepository) GetOpenCreditLots(ctx context.Context, userID uint64, at time.Time) ([]models.CreditLot, error) {
+	return lr.getCreditLots(ctx, selectOpenCreditLotsQuery, userID, at)
+}
+
+// GetExpiredCreditLots returns user credits which have expired at time and have unconsumed points, oldest first
+func (lr *LedgerRepository) GetExpiredCreditLots(ctx context.Context, userID uint64, at time.Time) ([]models.CreditLot, error) {
+	return lr.getCreditLots(ctx, selectExpiredCreditLotsQuery, userID, at)
+}
+
+// getCreditLots returns user credit lots selected by query
+func (lr *LedgerRepository) getCreditLots(ctx context.Context, query string, userID uint64, at time.Time) ([]models.CreditLot, error) {
+	rows, err := lr.db.Query(ctx, query, userID, models.LedgerAccountUser, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := []models.CreditLot{}
+
+	for rows.Next() {
+		lot := models.CreditLot{}
+		err = rows.Scan(&lot.EntryID, &lot.UserID, &lot.Amount, &lot.Remaining, &lot.ExpiresAt, &lot.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lots, nil
+}
+
+// GetUsersWithExpiredCredits returns ids of users having expired credits with unconsumed points
+func (lr *LedgerRepository) GetUsersWithExpiredCredits(ctx context.Context, at time.Time, limit int) ([]uint64, error) {
+	rows, err := lr.db.Query(ctx, selectUsersWithExpiredCreditsQuery, models.LedgerAccountUser, at, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []uint64{}
+
+	for rows.Next() {
+		var userID uint64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// CreateAllocation records consumption of credit by debit
+func (lr *LedgerRepository) CreateAllocation(ctx context.Context, alloc *models.LedgerAllocation) (*models.LedgerAllocation, error) {
+	err := lr.db.QueryRow(ctx, insertLedgerAllocationQuery, alloc.DebitEntryID, alloc.CreditEntryID, alloc.Amount).
+		Scan(&alloc.ID, &alloc.DebitEntryID, &alloc.CreditEntryID, &alloc.Amount, &alloc.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return alloc, nil
+}
+
+// CreateAdjustment records manual balance adjustment backed by ledger entry
+func (lr *LedgerRepository) CreateAdjustment(ctx context.Context, adj *models.Adjustment) (*models.Adjustment, error) {
+	err := lr.db.QueryRow(ctx, insertLedgerAdjustmentQuery, adj.UserID, adj.ActorID, adj.Amount, adj.Reason, adj.EntryID).
+		Scan(&adj.ID, &adj.UserID, &adj.ActorID, &adj.Amount, &adj.Reason, &adj.EntryID, &adj.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return adj, nil
+}
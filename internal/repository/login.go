@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+	"time"
+)
+
+const (
+	// failures older than window are forgotten, so counting starts again
+	recordLoginFailureQuery = `
+						INSERT INTO login_attempts (key, failures, last_failure_at)
+						values ($1, 1, now())
+						ON CONFLICT (key) DO UPDATE SET
+							failures = CASE
+								WHEN login_attempts.last_failure_at < now() - $2::interval
+									AND (login_attempts.locked_until IS NULL OR login_attempts.locked_until < now())
+								THEN 1
+								ELSE login_attempts.failures + 1
+							END,
+							last_failure_at = now()
+						RETURNING key, failures, last_failure_at, locked_until;
+`
+
+	selectLoginAttemptsQuery = `
+						SELECT key, failures, last_failure_at, locked_until FROM login_attempts
+						WHERE key = $1
+`
+
+	lockLoginQuery = `
+						UPDATE login_attempts SET locked_until = $2
+						WHERE key = $1
+`
+
+	deleteLoginAttemptsQuery = `
+						DELETE FROM login_attempts
+						WHERE key = $1
+`
+
+	insertLoginLockoutQuery = `
+						INSERT INTO login_lockouts (key, ip, failures, locked_until)
+						values ($1, $2, $3, $4)
+						RETURNING id, created_at;
+`
+)
+
+// LoginAttemptRepository implements login attempt repository interface
+type LoginAttemptRepository struct {
+	db postgres.Querier
+}
+
+// NewLoginAttemptRepository creates new LoginAttemptRepository instance
+func NewLoginAttemptRepository(db postgres.Querier) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// RecordLoginFailure counts failed attempt of key. Failures older than window are not counted.
+func (lr *LoginAttemptRepository) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error) {
+	attempts := models.LoginAttempts{}
+	err := lr.db.QueryRow(ctx, recordLoginFailureQuery, key, window).
+		Scan(&attempts.Key, &attempts.Failures, &attempts.LastFailureAt, &attempts.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempts, nil
+}
+
+// GetLoginAttempts returns failed attempts of key
+func (lr *LoginAttemptRepository) GetLoginAttempts(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	attempts := models.LoginAttempts{}
+	err := lr.db.QueryRow(ctx, selectLoginAttemptsQuery, key).
+		Scan(&attempts.Key, &attempts.Failures, &attempts.LastFailureAt, &attempts.LockedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrDataNotFound
+		}
+		return nil, err
+	}
+
+	return &attempts, nil
+}
+
+// LockLogin locks key out until given time
+func (lr *LoginAttemptRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := lr.db.Exec(ctx, lockLoginQuery, key, until)
+	return err
+}
+
+// ResetLoginAttempts forgets failed attempts of key
+func (lr *LoginAttemptRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := lr.db.Exec(ctx, deleteLoginAttemptsQuery, key)
+	return err
+}
+
+// CreateLockout inserts lockout audit record
+func (lr *LoginAttemptRepository) CreateLockout(ctx context.Context, lockout *models.LoginLockout) (*models.LoginLockout, error) {
+	err := lr.db.QueryRow(ctx, insertLoginLockoutQuery, lockout.Key, lockout.IP, lockout.Failures, lockout.LockedUntil).
+		Scan(&lockout.ID, &lockout.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return lockout, nil
+}
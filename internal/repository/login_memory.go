@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryLoginAttemptRepository keeps failed login attempts in memory. Attempts are not shared
+// between server instances and are lost on restart, but database is not hit by attackers.
+type MemoryLoginAttemptRepository struct {
+	mu        sync.Mutex
+	attempts  map[string]models.LoginAttempts
+	lastSweep time.Time
+}
+
+// NewMemoryLoginAttemptRepository creates new MemoryLoginAttemptRepository instance
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+	return &MemoryLoginAttemptRepository{
+		attempts:  make(map[string]models.LoginAttempts),
+		lastSweep: time.Now(),
+	}
+}
+
+// RecordLoginFailure counts failed attempt of key. Failures older than window are not counted.
+func (mr *MemoryLoginAttemptRepository) RecordLoginFailure(_ context.Context, key string, window time.Duration) (*models.LoginAttempts, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	now := time.Now()
+	mr.sweep(now, window)
+
+	attempts, ok := mr.attempts[key]
+	if !ok || isStale(attempts, now, window) {
+		attempts = models.LoginAttempts{Key: key}
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	mr.attempts[key] = attempts
+
+	return &attempts, nil
+}
+
+// GetLoginAttempts returns failed attempts of key
+func (mr *MemoryLoginAttemptRepository) GetLoginAttempts(_ context.Context, key string) (*models.LoginAttempts, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	attempts, ok := mr.attempts[key]
+	if !ok {
+		return nil, models.ErrDataNotFound
+	}
+
+	return &attempts, nil
+}
+
+// LockLogin locks key out until given time
+func (mr *MemoryLoginAttemptRepository) LockLogin(_ context.Context, key string, until time.Time) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if attempts, ok := mr.attempts[key]; ok {
+		attempts.LockedUntil = &until
+		mr.attempts[key] = attempts
+	}
+
+	return nil
+}
+
+// ResetLoginAttempts forgets failed attempts of key
+func (mr *MemoryLoginAttemptRepository) ResetLoginAttempts(_ context.Context, key string) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	delete(mr.attempts, key)
+
+	return nil
+}
+
+// sweep removes stale attempts once per window, so memory is not exhausted by many keys
+func (mr *MemoryLoginAttemptRepository) sweep(now time.Time, window time.Duration) {
+	if now.Sub(mr.lastSweep) < window {
+		return
+	}
+
+	for key, attempts := range mr.attempts {
+		if isStale(attempts, now, window) {
+			delete(mr.attempts, key)
+		}
+	}
+	mr.lastSweep = now
+}
+
+// isStale checks whether attempts are older than window and key is not locked
+func isStale(attempts models.LoginAttempts, now time.Time, window time.Duration) bool {
+	if attempts.LockedUntil != nil && attempts.LockedUntil.After(now) {
+		return false
+	}
+	return now.Sub(attempts.LastFailureAt) > window
+}
@@ -3,66 +3,238 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/rookgm/gophermart/internal/models"
 	"github.com/rookgm/gophermart/internal/repository/postgres"
+	"time"
 )
 
 const (
+	// insertOrderQuery inserts order which has not been uploaded yet, uploaded order is not returned
 	insertOrderQuery = `
-						INSERT INTO orders (user_id, number, status) 
-						values ($1, $2, $3)
-						RETURNING id, user_id, number, status, accrual, uploaded_at;
+						INSERT INTO orders (tenant_id, user_id, number, status) 
+						values ($1, $2, $3, $4)
+						ON CONFLICT (tenant_id, number) DO NOTHING
+						RETURNING id, tenant_id, user_id, number, status, accrual, uploaded_at;
+`
+	// insertOrdersQuery inserts orders which have not been uploaded yet and returns owner of each number.
+	// Main query does not see rows inserted by CTE, so orders joined are the ones uploaded before.
+	insertOrdersQuery = `
+						WITH input AS (
+							SELECT DISTINCT number FROM unnest($3::varchar[]) AS number
+						), inserted AS (
+							INSERT INTO orders (tenant_id, user_id, number, status)
+							SELECT $1, $2, number, $4 FROM input
+							ON CONFLICT (tenant_id, number) DO NOTHING
+							RETURNING number
+						)
+						SELECT i.number, ins.number IS NOT NULL, COALESCE(o.user_id, 0) FROM input i
+						LEFT JOIN inserted ins ON ins.number = i.number
+						LEFT JOIN orders o ON o.tenant_id = $1 AND o.number = i.number
+`
+
+	selectOrderOwnersQuery = `
+						SELECT number, user_id FROM orders
+						WHERE tenant_id = $1 AND number = ANY($2::varchar[])
 `
 	selectOrderByNumQuery = `
-						SELECT id, user_id, number, status, accrual, uploaded_at FROM orders
-						WHERE number = $1
+						SELECT id, tenant_id, user_id, number, status, accrual, uploaded_at FROM orders
+						WHERE tenant_id = $1 AND number = $2
 `
 
+	// selectOrdersByUserIDQuery selects user orders newest first, null filter values do not filter
 	selectOrdersByUserIDQuery = `
-						SELECT * FROM orders
+						SELECT id, tenant_id, user_id, number, status, accrual, uploaded_at FROM orders
 						WHERE user_id = $1
+							AND (COALESCE(cardinality($2::varchar[]), 0) = 0 OR status = ANY($2))
+							AND ($3::timestamptz IS NULL OR uploaded_at >= $3)
+							AND ($4::timestamptz IS NULL OR uploaded_at < $4)
+							AND ($5::timestamptz IS NULL OR (uploaded_at, id) < ($5, $6::bigint))
+						ORDER BY uploaded_at DESC, id DESC
+						LIMIT $7::integer
+`
+
+	selectOrdersDueForCheckQuery = `
+						SELECT id, tenant_id, user_id, number, status, accrual, uploaded_at, attempts, not_registered FROM orders
+						WHERE status = ANY($1) AND next_check_at <= now()
+						ORDER BY next_check_at
+						LIMIT $2
+`
+
+	scheduleOrderCheckQuery = `
+						UPDATE orders SET attempts = attempts + 1, next_check_at = now() + $2::bigint * interval '1 millisecond',
+							not_registered = CASE WHEN $3 THEN not_registered + 1 ELSE 0 END
+						WHERE id = $1
+`
+
+	countOrdersByStatusQuery = `
+						SELECT COUNT(*) FROM orders
+						WHERE user_id = $1 AND status = $2
+`
+
+	// requeueOrderQuery returns order to accrual queue, processed orders are never requeued
+	requeueOrderQuery = `
+						UPDATE orders SET status = $3, accrual = NULL, attempts = 0, not_registered = 0, next_check_at = now()
+						WHERE tenant_id = $1 AND number = $2 AND status <> $4
+						RETURNING id, tenant_id, user_id, number, status, accrual, uploaded_at
+`
+
+	// updateOrderStatusQuery never changes final order status and skips unchanged order.
+	// Order with changed status is checked again without delay.
+	updateOrderStatusQuery = `
+						UPDATE orders SET status = $2, accrual = $3, attempts = 0, not_registered = 0, next_check_at = now()
+						WHERE id = $1 AND status <> ALL($4)
+							AND (status <> $2 OR accrual IS DISTINCT FROM $3)
+						RETURNING id, tenant_id, user_id, number, status, accrual, uploaded_at
 `
 )
 
 // OrderRepository implements OrderRepository interface
 type OrderRepository struct {
-	db *postgres.DB
+	db postgres.Querier
 }
 
 // NewOrderRepository creates new OrderRepository instance
-func NewOrderRepository(db *postgres.DB) *OrderRepository {
+func NewOrderRepository(db postgres.Querier) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-// CreateOrder inserts new order to database
+// CreateOrder inserts new order to database. Returns ErrOrderLoadedUser or ErrOrderLoadedAnotherUser
+// if order has been uploaded before, including by concurrent request.
 func (or *OrderRepository) CreateOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
-	// check existing order
-	curOrder, err := or.GetOrderByNumber(ctx, order.Number)
+	err := or.db.QueryRow(ctx, insertOrderQuery, order.TenantID, order.UserID, order.Number, order.Status).
+		Scan(&order.ID, &order.TenantID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
 	if err == nil {
-		if curOrder.UserID == order.UserID {
-			// order has been loaded by user
-			return nil, models.ErrOrderLoadedUser
+		return order, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// insert waits for concurrent upload to commit, so existing order is visible here
+	curOrder, err := or.GetOrderByNumber(ctx, order.TenantID, order.Number)
+	if err != nil {
+		if errors.Is(err, models.ErrDataNotFound) {
+			// order can not disappear, orders are never deleted
+			return nil, fmt.Errorf("order %s conflicts but is not found", order.Number)
 		}
-		// order has been loaded by another user
-		return nil, models.ErrOrderLoadedAnotherUser
+		return nil, err
 	}
 
-	err = or.db.QueryRow(ctx, insertOrderQuery, order.UserID, order.Number, order.Status).Scan(&order.ID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
+	if curOrder.UserID == order.UserID {
+		// order has been loaded by user
+		return nil, models.ErrOrderLoadedUser
+	}
+	// order has been loaded by another user
+	return nil, models.ErrOrderLoadedAnotherUser
+}
+
+// CreateOrders inserts new orders of user with status in one round-trip and returns upload result
+// of each distinct number. Number inserted by concurrent request is reported as conflict.
+func (or *OrderRepository) CreateOrders(ctx context.Context, tenantID uint64, userID uint64, numbers []string, status string) ([]models.OrderUpload, error) {
+	rows, err := or.db.Query(ctx, insertOrdersQuery, tenantID, userID, numbers, status)
 	if err != nil {
-		if errCode := or.db.ErrorCode(err); errCode == "23505" {
-			return nil, models.ErrConflictData
+		return nil, err
+	}
+	defer rows.Close()
+
+	uploads := []models.OrderUpload{}
+	// orders inserted by concurrent transaction are not visible to the statement,
+	// their owners are selected again once the statement is done
+	var unresolved []string
+
+	for rows.Next() {
+		var (
+			upload   models.OrderUpload
+			inserted bool
+			ownerID  uint64
+		)
+		if err := rows.Scan(&upload.Number, &inserted, &ownerID); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case inserted:
+			upload.Result = models.OrderUploadAccepted
+		case ownerID == 0:
+			unresolved = append(unresolved, upload.Number)
+		default:
+			upload.Result = orderUploadResult(ownerID, userID)
 		}
+		uploads = append(uploads, upload)
+	}
+
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return order, nil
+	if len(unresolved) == 0 {
+		return uploads, nil
+	}
+
+	owners, err := or.getOrderOwners(ctx, tenantID, unresolved)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range uploads {
+		if uploads[i].Result != "" {
+			continue
+		}
+
+		ownerID, ok := owners[uploads[i].Number]
+		if !ok {
+			// order can not disappear, orders are never deleted
+			return nil, fmt.Errorf("order %s conflicts but is not found", uploads[i].Number)
+		}
+		uploads[i].Result = orderUploadResult(ownerID, userID)
+	}
+
+	return uploads, nil
 }
 
-// GetOrderByNumber returns order by number
-func (or *OrderRepository) GetOrderByNumber(ctx context.Context, num string) (*models.Order, error) {
+// getOrderOwners returns user ids of tenant orders by their numbers
+func (or *OrderRepository) getOrderOwners(ctx context.Context, tenantID uint64, numbers []string) (map[string]uint64, error) {
+	rows, err := or.db.Query(ctx, selectOrderOwnersQuery, tenantID, numbers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	owners := make(map[string]uint64, len(numbers))
+
+	for rows.Next() {
+		var (
+			number  string
+			ownerID uint64
+		)
+		if err := rows.Scan(&number, &ownerID); err != nil {
+			return nil, err
+		}
+		owners[number] = ownerID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return owners, nil
+}
+
+// orderUploadResult returns upload result of existing order owned by ownerID uploaded by userID
+func orderUploadResult(ownerID uint64, userID uint64) string {
+	if ownerID == userID {
+		return models.OrderUploadExisting
+	}
+	return models.OrderUploadConflict
+}
+
+// GetOrderByNumber returns order of tenant by number
+func (or *OrderRepository) GetOrderByNumber(ctx context.Context, tenantID uint64, num string) (*models.Order, error) {
 	order := models.Order{}
-	err := or.db.QueryRow(ctx, selectOrderByNumQuery, num).Scan(&order.ID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
+	err := or.db.QueryRow(ctx, selectOrderByNumQuery, tenantID, num).
+		Scan(&order.ID, &order.TenantID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, models.ErrDataNotFound
@@ -73,9 +245,25 @@ func (or *OrderRepository) GetOrderByNumber(ctx context.Context, num string) (*m
 	return &order, nil
 }
 
-// GetOrdersByUserID gets user orders
-func (or *OrderRepository) GetOrdersByUserID(ctx context.Context, userID uint64) ([]models.Order, error) {
-	rows, err := or.db.Query(ctx, selectOrdersByUserIDQuery, userID)
+// GetOrdersByUserID gets at most limit user orders matching filter, newest first.
+// All matching orders are returned if limit is zero.
+func (or *OrderRepository) GetOrdersByUserID(ctx context.Context, userID uint64, filter models.OrderFilter, limit int) ([]models.Order, error) {
+	var (
+		afterUploadedAt *time.Time
+		afterID         uint64
+	)
+	if filter.After != nil {
+		afterUploadedAt = &filter.After.UploadedAt
+		afterID = filter.After.ID
+	}
+
+	// LIMIT NULL does not limit rows
+	var maxRows *int
+	if limit > 0 {
+		maxRows = &limit
+	}
+
+	rows, err := or.db.Query(ctx, selectOrdersByUserIDQuery, userID, filter.Statuses, filter.From, filter.To, afterUploadedAt, afterID, maxRows)
 	if err != nil {
 		return nil, err
 	}
@@ -85,9 +273,35 @@ func (or *OrderRepository) GetOrdersByUserID(ctx context.Context, userID uint64)
 
 	for rows.Next() {
 		order := models.Order{}
-		err = rows.Scan(&order.ID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
+		err = rows.Scan(&order.ID, &order.TenantID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
 		if err != nil {
-			continue
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetOrdersDueForCheck returns orders having one of statuses which are due for accrual check, longest waiting first
+func (or *OrderRepository) GetOrdersDueForCheck(ctx context.Context, statuses []string, limit int) ([]models.Order, error) {
+	rows, err := or.db.Query(ctx, selectOrdersDueForCheckQuery, statuses, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+
+	for rows.Next() {
+		order := models.Order{}
+		err = rows.Scan(&order.ID, &order.TenantID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt, &order.Attempts, &order.NotRegistered)
+		if err != nil {
+			return nil, err
 		}
 		orders = append(orders, order)
 	}
@@ -98,3 +312,55 @@ func (or *OrderRepository) GetOrdersByUserID(ctx context.Context, userID uint64)
 
 	return orders, nil
 }
+
+// ScheduleOrderCheck counts accrual check which did not change order and postpones the next one by delay.
+// Consecutive checks of order unknown to accrual system are counted separately, any other result resets them.
+func (or *OrderRepository) ScheduleOrderCheck(ctx context.Context, id uint64, delay time.Duration, notRegistered bool) error {
+	_, err := or.db.Exec(ctx, scheduleOrderCheckQuery, id, delay.Milliseconds(), notRegistered)
+	return err
+}
+
+// UpdateOrderStatus updates order status and accrual.
+// Returns ErrDataNotFound if order does not exist, its status is already final or nothing changes.
+func (or *OrderRepository) UpdateOrderStatus(ctx context.Context, id uint64, status string, accrual *float64) (*models.Order, error) {
+	order := models.Order{}
+	err := or.db.QueryRow(ctx, updateOrderStatusQuery, id, status, accrual, []string{models.OrderStatusInvalid, models.OrderStatusProcessed}).
+		Scan(&order.ID, &order.TenantID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrDataNotFound
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// CountOrdersByStatus returns number of user orders having status
+func (or *OrderRepository) CountOrdersByStatus(ctx context.Context, userID uint64, status string) (int, error) {
+	var count int
+	if err := or.db.QueryRow(ctx, countOrdersByStatusQuery, userID, status).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RequeueOrder returns order of tenant to accrual queue. Returns ErrDataNotFound if order does not exist
+// and ErrOrderProcessed if accrual of order has already been credited.
+func (or *OrderRepository) RequeueOrder(ctx context.Context, tenantID uint64, number string) (*models.Order, error) {
+	order := models.Order{}
+	err := or.db.QueryRow(ctx, requeueOrderQuery, tenantID, number, models.OrderStatusNew, models.OrderStatusProcessed).
+		Scan(&order.ID, &order.TenantID, &order.UserID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, err := or.GetOrderByNumber(ctx, tenantID, number); err != nil {
+				return nil, err
+			}
+			return nil, models.ErrOrderProcessed
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
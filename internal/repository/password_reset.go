@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+)
+
+const (
+	insertPasswordResetTokenQuery = `
+						INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+						values ($1, $2, $3)
+						RETURNING id, user_id, token_hash, expires_at, used_at, created_at;
+`
+
+	selectPasswordResetTokenByHashQuery = `
+						SELECT id, user_id, token_hash, expires_at, used_at, created_at FROM password_reset_tokens
+						WHERE token_hash = $1
+`
+
+	usePasswordResetTokenQuery = `
+						UPDATE password_reset_tokens SET used_at = now()
+						WHERE id = $1 AND used_at IS NULL
+`
+
+	usePasswordResetTokensQuery = `
+						UPDATE password_reset_tokens SET used_at = now()
+						WHERE user_id = $1 AND used_at IS NULL
+`
+)
+
+// PasswordResetRepository implements password reset repository interface
+type PasswordResetRepository struct {
+	db postgres.Querier
+}
+
+// NewPasswordResetRepository creates new PasswordResetRepository instance
+func NewPasswordResetRepository(db postgres.Querier) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+// CreatePasswordResetToken inserts new password reset token
+func (pr *PasswordResetRepository) CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) (*models.PasswordResetToken, error) {
+	err := pr.db.QueryRow(ctx, insertPasswordResetTokenQuery, token.UserID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		if errCode := postgres.ErrorCode(err); errCode == "23505" {
+			return nil, models.ErrConflictData
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// GetPasswordResetTokenByHash returns password reset token by its hash
+func (pr *PasswordResetRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	token := models.PasswordResetToken{}
+	err := pr.db.QueryRow(ctx, selectPasswordResetTokenByHashQuery, hash).
+		Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrDataNotFound
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// UsePasswordResetToken marks token as used. Returns ErrDataNotFound if token has already been used.
+func (pr *PasswordResetRepository) UsePasswordResetToken(ctx context.Context, id uint64) error {
+	tag, err := pr.db.Exec(ctx, usePasswordResetTokenQuery, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// UsePasswordResetTokens marks all unused tokens of user as used
+func (pr *PasswordResetRepository) UsePasswordResetTokens(ctx context.Context, userID uint64) error {
+	_, err := pr.db.Exec(ctx, usePasswordResetTokensQuery, userID)
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -14,6 +15,13 @@ import (
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// Querier is interface for executing queries. It is implemented by connection pool and transaction.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type DB struct {
 	*pgxpool.Pool
 	url string
@@ -54,9 +62,33 @@ func (db *DB) Migrate() error {
 	return nil
 }
 
+// WithTx runs fn in transaction. Transaction is committed if fn succeeds and rolled back otherwise.
+func (db *DB) WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 // ErrorCode returns error code
 func (db *DB) ErrorCode(err error) string {
-	return err.(*pgconn.PgError).Code
+	return ErrorCode(err)
+}
+
+// ErrorCode returns PostgreSQL error code, or empty string if err is not PostgreSQL error
+func ErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
 }
 
 // Close closes database connections
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "withdrawals";
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "withdrawals" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" bigint NOT NULL,
+    "order_number" varchar NOT NULL,
+    "sum" numeric(10, 2) NOT NULL CHECK ("sum" > 0),
+    "processed_at" timestamptz NOT NULL DEFAULT (now()),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
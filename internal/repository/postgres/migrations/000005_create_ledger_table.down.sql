@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "ledger_entries";
+DROP FUNCTION IF EXISTS ledger_entries_append_only();
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS "ledger_entries" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" bigint NOT NULL,
+    "debit_account" varchar NOT NULL,
+    "credit_account" varchar NOT NULL,
+    "amount" numeric(12, 2) NOT NULL CHECK ("amount" > 0),
+    "order_id" bigint,
+    "withdrawal_id" bigint,
+    "reversal_of" bigint UNIQUE,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("debit_account" <> "credit_account"),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (order_id) REFERENCES orders(id),
+    FOREIGN KEY (withdrawal_id) REFERENCES withdrawals(id),
+    FOREIGN KEY (reversal_of) REFERENCES ledger_entries(id)
+);
+
+CREATE INDEX IF NOT EXISTS "ledger_entries_user_id_idx" ON "ledger_entries" ("user_id");
+
+-- ledger is append-only, mistakes are fixed by reversal entries
+CREATE OR REPLACE FUNCTION ledger_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "ledger_entries_append_only"
+    BEFORE UPDATE OR DELETE ON "ledger_entries"
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_append_only();
+
+-- move existing accruals and withdrawals to ledger
+INSERT INTO "ledger_entries" ("user_id", "debit_account", "credit_account", "amount", "order_id", "created_at")
+SELECT "user_id", 'accrual', 'user', "accrual", "id", "uploaded_at" FROM "orders"
+WHERE "status" = 'PROCESSED' AND "accrual" > 0;
+
+INSERT INTO "ledger_entries" ("user_id", "debit_account", "credit_account", "amount", "withdrawal_id", "created_at")
+SELECT "user_id", 'user', 'withdrawal', "sum", "id", "processed_at" FROM "withdrawals";
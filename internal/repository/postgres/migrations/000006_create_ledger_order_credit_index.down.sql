@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "ledger_entries_order_credit_idx";
\ No newline at end of file
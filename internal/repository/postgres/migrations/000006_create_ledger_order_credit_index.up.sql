@@ -0,0 +1,3 @@
+-- order accrual can be credited to user account only once
+CREATE UNIQUE INDEX IF NOT EXISTS "ledger_entries_order_credit_idx" ON "ledger_entries" ("order_id")
+WHERE "order_id" IS NOT NULL AND "debit_account" = 'accrual' AND "reversal_of" IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "ledger_entries_rule_bonus_idx";
+ALTER TABLE "ledger_entries" DROP COLUMN IF EXISTS "rule_id";
+DROP TABLE IF EXISTS "bonus_rules";
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "bonus_rules" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "kind" varchar NOT NULL CHECK ("kind" IN ('signup', 'first_order', 'period')),
+    "amount" numeric(12, 2) NOT NULL DEFAULT 0 CHECK ("amount" >= 0),
+    "multiplier" numeric(6, 2) NOT NULL DEFAULT 1 CHECK ("multiplier" >= 1),
+    "starts_at" timestamptz,
+    "ends_at" timestamptz,
+    "active" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "ledger_entries" ADD COLUMN IF NOT EXISTS "rule_id" bigint REFERENCES bonus_rules(id);
+
+-- bonus rule can be applied to user order, or to user itself, only once
+CREATE UNIQUE INDEX IF NOT EXISTS "ledger_entries_rule_bonus_idx" ON "ledger_entries" ("rule_id", "user_id", COALESCE("order_id", 0))
+WHERE "rule_id" IS NOT NULL AND "reversal_of" IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "ledger_allocations";
+DROP FUNCTION IF EXISTS append_only();
+DROP INDEX IF EXISTS "ledger_entries_expires_at_idx";
+ALTER TABLE "ledger_entries" DROP COLUMN IF EXISTS "expires_at";
@@ -0,0 +1,48 @@
+-- points credited to user account expire at expires_at, NULL means points never expire
+ALTER TABLE "ledger_entries" ADD COLUMN IF NOT EXISTS "expires_at" timestamptz;
+
+-- allocations record which credits of user account are consumed by debits, in FIFO order
+CREATE TABLE IF NOT EXISTS "ledger_allocations" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "debit_entry_id" bigint NOT NULL,
+    "credit_entry_id" bigint NOT NULL,
+    "amount" numeric(12, 2) NOT NULL CHECK ("amount" > 0),
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    FOREIGN KEY (debit_entry_id) REFERENCES ledger_entries(id),
+    FOREIGN KEY (credit_entry_id) REFERENCES ledger_entries(id)
+);
+
+CREATE INDEX IF NOT EXISTS "ledger_allocations_credit_entry_id_idx" ON "ledger_allocations" ("credit_entry_id");
+CREATE INDEX IF NOT EXISTS "ledger_entries_expires_at_idx" ON "ledger_entries" ("expires_at") WHERE "expires_at" IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "ledger_allocations_append_only"
+    BEFORE UPDATE OR DELETE ON "ledger_allocations"
+    FOR EACH ROW EXECUTE FUNCTION append_only();
+
+-- allocate existing debits of user account to credits in FIFO order: each debit consumes
+-- the part of running total of user credits overlapping its own running total
+WITH credits AS (
+    SELECT le."id", le."user_id",
+        SUM(le."amount") OVER (PARTITION BY le."user_id" ORDER BY le."created_at", le."id") - le."amount" AS "start_total",
+        SUM(le."amount") OVER (PARTITION BY le."user_id" ORDER BY le."created_at", le."id") AS "end_total"
+    FROM "ledger_entries" le
+    WHERE le."credit_account" = 'user' AND le."reversal_of" IS NULL
+        AND NOT EXISTS (SELECT 1 FROM "ledger_entries" r WHERE r."reversal_of" = le."id")
+), debits AS (
+    SELECT le."id", le."user_id", le."created_at",
+        SUM(le."amount") OVER (PARTITION BY le."user_id" ORDER BY le."created_at", le."id") - le."amount" AS "start_total",
+        SUM(le."amount") OVER (PARTITION BY le."user_id" ORDER BY le."created_at", le."id") AS "end_total"
+    FROM "ledger_entries" le
+    WHERE le."debit_account" = 'user' AND le."reversal_of" IS NULL
+        AND NOT EXISTS (SELECT 1 FROM "ledger_entries" r WHERE r."reversal_of" = le."id")
+)
+INSERT INTO "ledger_allocations" ("debit_entry_id", "credit_entry_id", "amount", "created_at")
+SELECT d."id", c."id", LEAST(c."end_total", d."end_total") - GREATEST(c."start_total", d."start_total"), d."created_at"
+FROM debits d
+JOIN credits c ON c."user_id" = d."user_id" AND c."start_total" < d."end_total" AND d."start_total" < c."end_total";
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" bigint NOT NULL,
+    "family_id" uuid NOT NULL,
+    "token_hash" varchar NOT NULL UNIQUE,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS "refresh_tokens_family_id_idx" ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS "refresh_tokens_user_id_idx" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "revoked_tokens" (
+    "id" uuid PRIMARY KEY,
+    "user_id" bigint NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz NOT NULL DEFAULT (now()),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
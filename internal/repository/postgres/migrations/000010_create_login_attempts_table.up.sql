@@ -0,0 +1,25 @@
+-- failed login attempts counted by throttle key: "login:<login>" or "ip:<address>"
+CREATE TABLE IF NOT EXISTS "login_attempts" (
+    "key" varchar PRIMARY KEY,
+    "failures" integer NOT NULL CHECK ("failures" > 0),
+    "last_failure_at" timestamptz NOT NULL DEFAULT (now()),
+    "locked_until" timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS "login_attempts_last_failure_at_idx" ON "login_attempts" ("last_failure_at");
+
+-- audit record of lockouts
+CREATE TABLE IF NOT EXISTS "login_lockouts" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "key" varchar NOT NULL,
+    "ip" varchar NOT NULL,
+    "failures" integer NOT NULL,
+    "locked_until" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "login_lockouts_key_idx" ON "login_lockouts" ("key", "created_at" DESC);
+
+CREATE TRIGGER "login_lockouts_append_only"
+    BEFORE UPDATE OR DELETE ON "login_lockouts"
+    FOR EACH ROW EXECUTE FUNCTION append_only();
@@ -0,0 +1,19 @@
+-- one-time password reset tokens, only token hash is stored
+CREATE TABLE IF NOT EXISTS "password_reset_tokens" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" bigint NOT NULL,
+    "token_hash" varchar NOT NULL UNIQUE,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS "password_reset_tokens_user_id_idx" ON "password_reset_tokens" ("user_id");
+
+-- access tokens of user issued before revoked_before are revoked, e.g. after password change
+CREATE TABLE IF NOT EXISTS "revoked_user_tokens" (
+    "user_id" bigint PRIMARY KEY,
+    "revoked_before" timestamptz NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
@@ -0,0 +1,3 @@
+-- role of user, admins are assigned by hand: UPDATE users SET role = 'admin' WHERE login = '...'
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "role" varchar NOT NULL DEFAULT 'user'
+    CHECK ("role" IN ('user', 'support', 'admin'));
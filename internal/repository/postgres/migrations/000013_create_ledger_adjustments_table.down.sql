@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "users_login_pattern_idx";
+DROP TABLE IF EXISTS "ledger_adjustments";
@@ -0,0 +1,21 @@
+-- manual balance adjustments made by back-office staff, each is backed by ledger entry
+CREATE TABLE IF NOT EXISTS "ledger_adjustments" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" bigint NOT NULL,
+    "actor_id" bigint NOT NULL,
+    "amount" numeric(12, 2) NOT NULL CHECK ("amount" <> 0),
+    "reason" varchar NOT NULL CHECK (length(trim("reason")) > 0),
+    "entry_id" bigint NOT NULL UNIQUE,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (actor_id) REFERENCES users(id),
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
+);
+
+CREATE INDEX IF NOT EXISTS "ledger_adjustments_user_id_idx" ON "ledger_adjustments" ("user_id");
+
+CREATE TRIGGER "ledger_adjustments_append_only"
+    BEFORE UPDATE OR DELETE ON "ledger_adjustments"
+    FOR EACH ROW EXECUTE FUNCTION append_only();
+
+CREATE INDEX IF NOT EXISTS "users_login_pattern_idx" ON "users" ("login" varchar_pattern_ops);
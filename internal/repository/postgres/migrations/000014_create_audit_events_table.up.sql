@@ -0,0 +1,20 @@
+-- security and financial events, actor is user who performed action, user is user affected by it
+CREATE TABLE IF NOT EXISTS "audit_events" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "type" varchar NOT NULL,
+    "actor_id" bigint,
+    "user_id" bigint,
+    "ip" varchar NOT NULL DEFAULT '',
+    "user_agent" varchar NOT NULL DEFAULT '',
+    "request_id" varchar NOT NULL DEFAULT '',
+    "details" jsonb NOT NULL DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "audit_events_type_idx" ON "audit_events" ("type", "id" DESC);
+CREATE INDEX IF NOT EXISTS "audit_events_user_id_idx" ON "audit_events" ("user_id", "id" DESC);
+CREATE INDEX IF NOT EXISTS "audit_events_actor_id_idx" ON "audit_events" ("actor_id", "id" DESC);
+
+CREATE TRIGGER "audit_events_append_only"
+    BEFORE UPDATE OR DELETE ON "audit_events"
+    FOR EACH ROW EXECUTE FUNCTION append_only();
@@ -0,0 +1,12 @@
+ALTER TABLE "orders" DROP CONSTRAINT IF EXISTS "orders_tenant_id_number_key";
+ALTER TABLE "orders" ADD CONSTRAINT "orders_number_key" UNIQUE ("number");
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "tenant_id";
+
+DROP INDEX IF EXISTS "users_tenant_login_pattern_idx";
+CREATE INDEX IF NOT EXISTS "users_login_pattern_idx" ON "users" ("login" varchar_pattern_ops);
+
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_tenant_id_login_key";
+ALTER TABLE "users" ADD CONSTRAINT "users_login_key" UNIQUE ("login");
+ALTER TABLE "users" DROP COLUMN IF EXISTS "tenant_id";
+
+DROP TABLE IF EXISTS "tenants";
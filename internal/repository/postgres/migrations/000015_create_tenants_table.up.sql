@@ -0,0 +1,27 @@
+-- storefronts served by deployment, tenants are added by hand:
+-- INSERT INTO tenants (slug, name, host, accrual_address) VALUES ('...', '...', '...', '...')
+CREATE TABLE IF NOT EXISTS "tenants" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "slug" varchar NOT NULL UNIQUE,
+    "name" varchar NOT NULL,
+    "host" varchar UNIQUE,
+    "accrual_address" varchar,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- existing users and orders belong to default tenant
+INSERT INTO "tenants" ("id", "slug", "name") VALUES (1, 'default', 'Default') ON CONFLICT DO NOTHING;
+SELECT setval(pg_get_serial_sequence('tenants', 'id'), (SELECT MAX("id") FROM "tenants"));
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "tenant_id" bigint NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE "users" ALTER COLUMN "tenant_id" DROP DEFAULT;
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_login_key";
+ALTER TABLE "users" ADD CONSTRAINT "users_tenant_id_login_key" UNIQUE ("tenant_id", "login");
+
+DROP INDEX IF EXISTS "users_login_pattern_idx";
+CREATE INDEX IF NOT EXISTS "users_tenant_login_pattern_idx" ON "users" ("tenant_id", "login" varchar_pattern_ops);
+
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "tenant_id" bigint NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE "orders" ALTER COLUMN "tenant_id" DROP DEFAULT;
+ALTER TABLE "orders" DROP CONSTRAINT IF EXISTS "orders_number_key";
+ALTER TABLE "orders" ADD CONSTRAINT "orders_tenant_id_number_key" UNIQUE ("tenant_id", "number");
@@ -0,0 +1,4 @@
+ALTER TABLE "tenants" DROP COLUMN IF EXISTS "order_checksum";
+ALTER TABLE "tenants" DROP COLUMN IF EXISTS "order_max_length";
+ALTER TABLE "tenants" DROP COLUMN IF EXISTS "order_min_length";
+ALTER TABLE "tenants" DROP COLUMN IF EXISTS "order_prefix";
@@ -0,0 +1,9 @@
+-- order number format of tenant, by default any number passing Luhn check is accepted
+ALTER TABLE "tenants" ADD COLUMN IF NOT EXISTS "order_prefix" varchar NOT NULL DEFAULT ''
+    CHECK ("order_prefix" ~ '^[0-9]*$');
+ALTER TABLE "tenants" ADD COLUMN IF NOT EXISTS "order_min_length" integer NOT NULL DEFAULT 0
+    CHECK ("order_min_length" >= 0);
+ALTER TABLE "tenants" ADD COLUMN IF NOT EXISTS "order_max_length" integer NOT NULL DEFAULT 0
+    CHECK ("order_max_length" >= 0);
+ALTER TABLE "tenants" ADD COLUMN IF NOT EXISTS "order_checksum" varchar NOT NULL DEFAULT 'luhn'
+    CHECK ("order_checksum" IN ('luhn', 'none'));
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "orders_user_id_uploaded_at_idx";
@@ -0,0 +1,2 @@
+-- user orders are listed newest first, id breaks ties of keyset pagination
+CREATE INDEX IF NOT EXISTS "orders_user_id_uploaded_at_idx" ON "orders" ("user_id", "uploaded_at" DESC, "id" DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "orders_next_check_at_idx";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "attempts";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "next_check_at";
@@ -0,0 +1,5 @@
+-- orders are checked in accrual system at next_check_at, attempts counts checks which did not change order
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "next_check_at" timestamptz NOT NULL DEFAULT (now());
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "attempts" integer NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS "orders_next_check_at_idx" ON "orders" ("next_check_at") WHERE "status" IN ('NEW', 'PROCESSING');
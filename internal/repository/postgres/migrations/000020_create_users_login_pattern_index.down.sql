@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "users_tenant_login_pattern_idx";
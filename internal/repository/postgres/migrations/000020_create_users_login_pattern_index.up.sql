@@ -0,0 +1,3 @@
+-- login prefix search of back-office, LIKE uses index only with pattern operator class.
+-- The index was created along with other tables before, it is kept here from now on.
+CREATE INDEX IF NOT EXISTS "users_tenant_login_pattern_idx" ON "users" ("tenant_id", "login" varchar_pattern_ops);
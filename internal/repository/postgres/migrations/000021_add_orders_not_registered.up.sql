@@ -0,0 +1,3 @@
+-- not_registered counts consecutive checks answered that accrual system does not know order,
+-- failed requests do not count, so orders are not invalidated after accrual system outage
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "not_registered" integer NOT NULL DEFAULT 0;
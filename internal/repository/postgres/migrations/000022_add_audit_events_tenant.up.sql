@@ -0,0 +1,13 @@
+-- events are listed by tenant they happened in, failed logins of unknown logins have no user
+-- to take tenant from, so tenant is stored with event
+ALTER TABLE "audit_events" ADD COLUMN IF NOT EXISTS "tenant_id" bigint REFERENCES tenants(id);
+
+-- existing events belong to tenant of affected user or actor, events of no user to default tenant
+ALTER TABLE "audit_events" DISABLE TRIGGER "audit_events_append_only";
+UPDATE "audit_events" e SET "tenant_id" = COALESCE(
+    (SELECT u."tenant_id" FROM "users" u WHERE u."id" = COALESCE(e."user_id", e."actor_id")), 1);
+ALTER TABLE "audit_events" ENABLE TRIGGER "audit_events_append_only";
+
+ALTER TABLE "audit_events" ALTER COLUMN "tenant_id" SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "audit_events_tenant_id_idx" ON "audit_events" ("tenant_id", "id" DESC);
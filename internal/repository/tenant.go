@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+)
+
+const (
+	selectTenantsQuery = `
+						SELECT id, slug, name, COALESCE(host, ''), COALESCE(accrual_address, ''),
+							order_prefix, order_min_length, order_max_length, order_checksum, created_at
+						FROM tenants
+						ORDER BY id
+`
+)
+
+// TenantRepository implements tenant repository interface
+type TenantRepository struct {
+	db postgres.Querier
+}
+
+// NewTenantRepository creates new TenantRepository instance
+func NewTenantRepository(db postgres.Querier) *TenantRepository {
+	return &TenantRepository{db: db}
+}
+
+// GetTenants returns all tenants
+func (tr *TenantRepository) GetTenants(ctx context.Context) ([]models.Tenant, error) {
+	rows, err := tr.db.Query(ctx, selectTenantsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := []models.Tenant{}
+
+	for rows.Next() {
+		tenant := models.Tenant{}
+		err = rows.Scan(&tenant.ID, &tenant.Slug, &tenant.Name, &tenant.Host, &tenant.AccrualAddress,
+			&tenant.OrderFormat.Prefix, &tenant.OrderFormat.MinLength, &tenant.OrderFormat.MaxLength, &tenant.OrderFormat.Checksum,
+			&tenant.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tenants, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+	"time"
+)
+
+const (
+	insertRefreshTokenQuery = `
+						INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+						values ($1, $2, $3, $4)
+						RETURNING id, user_id, family_id, token_hash, expires_at, revoked_at, created_at;
+`
+
+	selectRefreshTokenByHashQuery = `
+						SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, created_at FROM refresh_tokens
+						WHERE token_hash = $1
+`
+
+	revokeRefreshTokenQuery = `
+						UPDATE refresh_tokens SET revoked_at = now()
+						WHERE id = $1 AND revoked_at IS NULL
+`
+
+	revokeRefreshTokenFamilyQuery = `
+						UPDATE refresh_tokens SET revoked_at = now()
+						WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+	revokeAccessTokenQuery = `
+						INSERT INTO revoked_tokens (id, user_id, expires_at)
+						values ($1, $2, $3)
+						ON CONFLICT (id) DO NOTHING
+`
+
+	deleteExpiredRevokedTokensQuery = `
+						DELETE FROM revoked_tokens WHERE expires_at < $1
+`
+
+	selectRevokedTokenQuery = `
+						SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE id = $1)
+							OR EXISTS (SELECT 1 FROM revoked_user_tokens WHERE user_id = $2 AND revoked_before > $3)
+`
+
+	revokeUserRefreshTokensQuery = `
+						UPDATE refresh_tokens SET revoked_at = now()
+						WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+	// issue time of access tokens has second precision, so revocation time is truncated
+	// to keep tokens issued right after revocation valid
+	revokeUserAccessTokensQuery = `
+						INSERT INTO revoked_user_tokens (user_id, revoked_before)
+						values ($1, date_trunc('second', now()))
+						ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before
+`
+)
+
+// TokenRepository implements token repository interface
+type TokenRepository struct {
+	db postgres.Querier
+}
+
+// NewTokenRepository creates new TokenRepository instance
+func NewTokenRepository(db postgres.Querier) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// CreateRefreshToken inserts new refresh token to database
+func (tr *TokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	err := tr.db.QueryRow(ctx, insertRefreshTokenQuery, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &token.RevokedAt, &token.CreatedAt)
+	if err != nil {
+		if errCode := postgres.ErrorCode(err); errCode == "23505" {
+			return nil, models.ErrConflictData
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// GetRefreshTokenByHash returns refresh token by its hash
+func (tr *TokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	token := models.RefreshToken{}
+	err := tr.db.QueryRow(ctx, selectRefreshTokenByHashQuery, hash).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &token.RevokedAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrDataNotFound
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshToken revokes refresh token. Returns ErrDataNotFound if token has already been revoked.
+func (tr *TokenRepository) RevokeRefreshToken(ctx context.Context, id uint64) error {
+	tag, err := tr.db.Exec(ctx, revokeRefreshTokenQuery, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes all refresh tokens rotated from the same login
+func (tr *TokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := tr.db.Exec(ctx, revokeRefreshTokenFamilyQuery, familyID)
+	return err
+}
+
+// RevokeAccessToken adds access token to revocation list
+func (tr *TokenRepository) RevokeAccessToken(ctx context.Context, id uuid.UUID, userID uint64, expiresAt time.Time) error {
+	_, err := tr.db.Exec(ctx, revokeAccessTokenQuery, id, userID, expiresAt)
+	return err
+}
+
+// DeleteExpiredRevokedTokens deletes access tokens expired before time from revocation list
+func (tr *TokenRepository) DeleteExpiredRevokedTokens(ctx context.Context, before time.Time) error {
+	_, err := tr.db.Exec(ctx, deleteExpiredRevokedTokensQuery, before)
+	return err
+}
+
+// RevokeUserTokens revokes all refresh tokens of user and access tokens issued until now
+func (tr *TokenRepository) RevokeUserTokens(ctx context.Context, userID uint64) error {
+	if _, err := tr.db.Exec(ctx, revokeUserRefreshTokensQuery, userID); err != nil {
+		return err
+	}
+
+	_, err := tr.db.Exec(ctx, revokeUserAccessTokensQuery, userID)
+	return err
+}
+
+// IsAccessTokenRevoked checks whether access token is in revocation list
+// or all tokens of its user issued before it were revoked
+func (tr *TokenRepository) IsAccessTokenRevoked(ctx context.Context, payload *models.TokenPayload) (bool, error) {
+	var revoked bool
+	if err := tr.db.QueryRow(ctx, selectRevokedTokenQuery, payload.ID, payload.UserID, payload.IssuedAt).Scan(&revoked); err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+)
+
+// UnitOfWork runs repository calls in single transaction
+type UnitOfWork struct {
+	db *postgres.DB
+}
+
+// NewUnitOfWork creates new UnitOfWork instance
+func NewUnitOfWork(db *postgres.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// WithTx runs fn with repositories bound to single transaction.
+// Transaction is committed if fn succeeds and rolled back otherwise.
+func (uow *UnitOfWork) WithTx(ctx context.Context, fn func(repos *TxRepositories) error) error {
+	return uow.db.WithTx(ctx, func(tx pgx.Tx) error {
+		return fn(newTxRepositories(tx))
+	})
+}
+
+// TxRepositories is set of repositories bound to transaction
+type TxRepositories struct {
+	users       *UserRepository
+	orders      *OrderRepository
+	withdrawals *WithdrawalRepository
+	ledger      *LedgerRepository
+	bonusRules  *BonusRuleRepository
+	tokens      *TokenRepository
+	resets      *PasswordResetRepository
+}
+
+// newTxRepositories creates repositories bound to transaction
+func newTxRepositories(tx pgx.Tx) *TxRepositories {
+	return &TxRepositories{
+		users:       NewUserRepository(tx),
+		orders:      NewOrderRepository(tx),
+		withdrawals: NewWithdrawalRepository(tx),
+		ledger:      NewLedgerRepository(tx),
+		bonusRules:  NewBonusRuleRepository(tx),
+		tokens:      NewTokenRepository(tx),
+		resets:      NewPasswordResetRepository(tx),
+	}
+}
+
+// Users returns user repository bound to transaction
+func (r *TxRepositories) Users() *UserRepository {
+	return r.users
+}
+
+// Orders returns order repository bound to transaction
+func (r *TxRepositories) Orders() *OrderRepository {
+	return r.orders
+}
+
+// Withdrawals returns withdrawal repository bound to transaction
+func (r *TxRepositories) Withdrawals() *WithdrawalRepository {
+	return r.withdrawals
+}
+
+// Ledger returns ledger repository bound to transaction
+func (r *TxRepositories) Ledger() *LedgerRepository {
+	return r.ledger
+}
+
+// BonusRules returns bonus rule repository bound to transaction
+func (r *TxRepositories) BonusRules() *BonusRuleRepository {
+	return r.bonusRules
+}
+
+// Tokens returns token repository bound to transaction
+func (r *TxRepositories) Tokens() *TokenRepository {
+	return r.tokens
+}
+
+// PasswordResets returns password reset repository bound to transaction
+func (r *TxRepositories) PasswordResets() *PasswordResetRepository {
+	return r.resets
+}
@@ -6,36 +6,70 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/rookgm/gophermart/internal/models"
 	"github.com/rookgm/gophermart/internal/repository/postgres"
+	"strings"
 )
 
+// likeEscaper escapes LIKE wildcards
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 const (
 	insertUserQuery = `
-					INSERT INTO users (login, password) 
-					values ($1, $2)
-					RETURNING id, login, password, created_at;
+					INSERT INTO users (tenant_id, login, password, role) 
+					values ($1, $2, $3, $4)
+					RETURNING id, tenant_id, login, password, role, created_at;
 `
 
 	selectUserByLoginQuery = `
-					SELECT id, login, password, created_at FROM users
-					WHERE login = $1
+					SELECT id, tenant_id, login, password, role, created_at FROM users
+					WHERE tenant_id = $1 AND login = $2
+`
+
+	selectUserByIDQuery = `
+					SELECT id, tenant_id, login, password, role, created_at FROM users
+					WHERE id = $1
+`
+
+	// searchUsersByLoginQuery selects users of tenant which login starts with pattern
+	searchUsersByLoginQuery = `
+					SELECT id, tenant_id, login, password, role, created_at FROM users
+					WHERE tenant_id = $1 AND login LIKE $2
+					ORDER BY login
+					LIMIT $3
+`
+
+	updateUserPasswordQuery = `
+					UPDATE users SET password = $2
+					WHERE id = $1
+`
+
+	replaceUserPasswordQuery = `
+					UPDATE users SET password = $3
+					WHERE id = $1 AND password = $2
+`
+
+	lockUserByIDQuery = `
+					SELECT id FROM users
+					WHERE id = $1
+					FOR UPDATE
 `
 )
 
 // UserRepository implements user repository interface
 type UserRepository struct {
-	db *postgres.DB
+	db postgres.Querier
 }
 
 // NewUserRepository creates new user repository instance
-func NewUserRepository(db *postgres.DB) *UserRepository {
+func NewUserRepository(db postgres.Querier) *UserRepository {
 	return &UserRepository{db: db}
 }
 
 // CreateUser insert new user into database
 func (ur *UserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
-	err := ur.db.QueryRow(ctx, insertUserQuery, user.Login, user.Password).Scan(&user.ID, &user.Login, &user.Password, &user.CreatedAt)
+	err := ur.db.QueryRow(ctx, insertUserQuery, user.TenantID, user.Login, user.Password, user.Role).
+		Scan(&user.ID, &user.TenantID, &user.Login, &user.Password, &user.Role, &user.CreatedAt)
 	if err != nil {
-		if errCode := ur.db.ErrorCode(err); errCode == "23505" {
+		if errCode := postgres.ErrorCode(err); errCode == "23505" {
 			return nil, models.ErrConflictData
 		}
 		return nil, err
@@ -44,10 +78,11 @@ func (ur *UserRepository) CreateUser(ctx context.Context, user *models.User) (*m
 	return user, nil
 }
 
-// GetUserByLogin returns user by login
-func (ur *UserRepository) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+// GetUserByLogin returns user of tenant by login
+func (ur *UserRepository) GetUserByLogin(ctx context.Context, tenantID uint64, login string) (*models.User, error) {
 	user := models.User{}
-	err := ur.db.QueryRow(ctx, selectUserByLoginQuery, login).Scan(&user.ID, &user.Login, &user.Password, &user.CreatedAt)
+	err := ur.db.QueryRow(ctx, selectUserByLoginQuery, tenantID, login).
+		Scan(&user.ID, &user.TenantID, &user.Login, &user.Password, &user.Role, &user.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, models.ErrDataNotFound
@@ -57,3 +92,91 @@ func (ur *UserRepository) GetUserByLogin(ctx context.Context, login string) (*mo
 
 	return &user, nil
 }
+
+// GetUserByID returns user by id
+func (ur *UserRepository) GetUserByID(ctx context.Context, id uint64) (*models.User, error) {
+	user := models.User{}
+	err := ur.db.QueryRow(ctx, selectUserByIDQuery, id).
+		Scan(&user.ID, &user.TenantID, &user.Login, &user.Password, &user.Role, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrDataNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// SearchUsersByLogin returns users of tenant which login starts with prefix, ordered by login
+func (ur *UserRepository) SearchUsersByLogin(ctx context.Context, tenantID uint64, prefix string, limit int) ([]models.User, error) {
+	rows, err := ur.db.Query(ctx, searchUsersByLoginQuery, tenantID, likePrefix(prefix), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+
+	for rows.Next() {
+		user := models.User{}
+		if err := rows.Scan(&user.ID, &user.TenantID, &user.Login, &user.Password, &user.Role, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// likePrefix returns LIKE pattern matching strings starting with prefix
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+// UpdatePassword replaces password hash of user
+func (ur *UserRepository) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	tag, err := ur.db.Exec(ctx, updateUserPasswordQuery, id, password)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// ReplacePassword replaces password hash of user if it is still oldPassword.
+// Returns ErrDataNotFound if password has been changed.
+func (ur *UserRepository) ReplacePassword(ctx context.Context, id uint64, oldPassword string, newPassword string) error {
+	tag, err := ur.db.Exec(ctx, replaceUserPasswordQuery, id, oldPassword, newPassword)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// LockUserByID locks user row until the end of transaction
+func (ur *UserRepository) LockUserByID(ctx context.Context, id uint64) error {
+	var userID uint64
+	err := ur.db.QueryRow(ctx, lockUserByIDQuery, id).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ErrDataNotFound
+		}
+		return err
+	}
+
+	return nil
+}
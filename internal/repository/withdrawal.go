@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"github.com/rookgm/gophermart/internal/repository/postgres"
+)
+
+const (
+	insertWithdrawalQuery = `
+						INSERT INTO withdrawals (user_id, order_number, sum)
+						values ($1, $2, $3)
+						RETURNING id, user_id, order_number, sum, processed_at;
+`
+
+	selectWithdrawalsByUserIDQuery = `
+						SELECT id, user_id, order_number, sum, processed_at FROM withdrawals
+						WHERE user_id = $1
+						ORDER BY processed_at DESC
+`
+)
+
+// WithdrawalRepository implements withdrawal repository interface
+type WithdrawalRepository struct {
+	db postgres.Querier
+}
+
+// NewWithdrawalRepository creates new WithdrawalRepository instance
+func NewWithdrawalRepository(db postgres.Querier) *WithdrawalRepository {
+	return &WithdrawalRepository{db: db}
+}
+
+// CreateWithdrawal inserts new withdrawal to database
+func (wr *WithdrawalRepository) CreateWithdrawal(ctx context.Context, withdrawal *models.Withdrawal) (*models.Withdrawal, error) {
+	err := wr.db.QueryRow(ctx, insertWithdrawalQuery, withdrawal.UserID, withdrawal.Order, withdrawal.Sum).
+		Scan(&withdrawal.ID, &withdrawal.UserID, &withdrawal.Order, &withdrawal.Sum, &withdrawal.ProcessedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
+
+// GetWithdrawalsByUserID returns user withdrawals, newest first
+func (wr *WithdrawalRepository) GetWithdrawalsByUserID(ctx context.Context, userID uint64) ([]models.Withdrawal, error) {
+	rows, err := wr.db.Query(ctx, selectWithdrawalsByUserIDQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	withdrawals := []models.Withdrawal{}
+
+	for rows.Next() {
+		withdrawal := models.Withdrawal{}
+		err = rows.Scan(&withdrawal.ID, &withdrawal.UserID, &withdrawal.Order, &withdrawal.Sum, &withdrawal.ProcessedAt)
+		if err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
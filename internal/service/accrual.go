@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"time"
+)
+
+// OrderBonusService is interface for crediting order bonuses
+type OrderBonusService interface {
+	// ApplyOrderBonuses credits bonuses for processed order
+	ApplyOrderBonuses(ctx context.Context, repos TxRepositories, order *models.Order) error
+}
+
+// AccrualService implements AccrualService interface
+type AccrualService struct {
+	uow       UnitOfWork
+	bonusSvc  OrderBonusService
+	audit     AuditRecorder
+	pointsTTL time.Duration
+}
+
+// NewAccrualService creates new AccrualService instance. Credited accrual points expire after pointsTTL, zero means never.
+func NewAccrualService(uow UnitOfWork, bonusSvc OrderBonusService, audit AuditRecorder, pointsTTL time.Duration) *AccrualService {
+	return &AccrualService{uow: uow, bonusSvc: bonusSvc, audit: audit, pointsTTL: pointsTTL}
+}
+
+// ApplyAccrual updates order status and accrual. Accrual of processed order is credited
+// to user account together with order bonuses in the same transaction, so they are credited
+// exactly once even if accrual system result is applied repeatedly.
+func (as *AccrualService) ApplyAccrual(ctx context.Context, orderID uint64, status string, accrual *float64) error {
+	var order *models.Order
+	err := as.uow.WithTx(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders().UpdateOrderStatus(ctx, orderID, status, accrual)
+		if err != nil {
+			if errors.Is(err, models.ErrDataNotFound) {
+				// order status is already final or has not changed
+				order = nil
+				return nil
+			}
+			return err
+		}
+
+		if order.Status != models.OrderStatusProcessed {
+			return nil
+		}
+
+		if order.Accrual != nil && *order.Accrual > 0 {
+			_, err = repos.Ledger().CreateEntry(ctx, &models.LedgerEntry{
+				UserID:        order.UserID,
+				DebitAccount:  models.LedgerAccountAccrual,
+				CreditAccount: models.LedgerAccountUser,
+				Amount:        *order.Accrual,
+				OrderID:       &order.ID,
+				ExpiresAt:     expiresAt(as.pointsTTL),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return as.bonusSvc.ApplyOrderBonuses(ctx, repos, order)
+	})
+	if err != nil || order == nil {
+		return err
+	}
+
+	as.audit.Record(ctx, auditEvent(models.AuditOrderStatusChanged, order.TenantID, 0, order.UserID, map[string]interface{}{
+		"order":   order.Number,
+		"status":  order.Status,
+		"accrual": order.Accrual,
+	}))
+
+	return nil
+}
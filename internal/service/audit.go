@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"go.uber.org/zap"
+)
+
+// requestInfoKey is context key of client request metadata
+type requestInfoKey struct{}
+
+// AuditRepository is interface for interacting with audit log
+type AuditRepository interface {
+	// CreateEvent appends event to audit log
+	CreateEvent(ctx context.Context, event *models.AuditEvent) (*models.AuditEvent, error)
+	// GetEvents returns events matching filter, newest first
+	GetEvents(ctx context.Context, filter models.AuditFilter, limit int) ([]models.AuditEvent, error)
+}
+
+// AuditRecorder is interface for recording audit events
+type AuditRecorder interface {
+	// Record records event made within request of ctx
+	Record(ctx context.Context, event *models.AuditEvent)
+}
+
+// AuditService records audit events to repository
+type AuditService struct {
+	repo   AuditRepository
+	logger *zap.Logger
+}
+
+// NewAuditService creates AuditService instance
+func NewAuditService(repo AuditRepository, logger *zap.Logger) *AuditService {
+	return &AuditService{repo: repo, logger: logger}
+}
+
+// Record records event with client request metadata of ctx. Events are recorded after action
+// has been committed, so failure to record does not undo it and is logged instead.
+func (as *AuditService) Record(ctx context.Context, event *models.AuditEvent) {
+	info := RequestInfoFromContext(ctx)
+	event.IP = info.IP
+	event.UserAgent = info.UserAgent
+	event.RequestID = info.RequestID
+
+	// event is recorded even if client has gone
+	if _, err := as.repo.CreateEvent(context.WithoutCancel(ctx), event); err != nil {
+		as.logger.Error("Error recording audit event",
+			zap.String("type", event.Type),
+			zap.String("request_id", event.RequestID),
+			zap.Error(err),
+		)
+	}
+}
+
+// ListEvents returns events matching filter, newest first
+func (as *AuditService) ListEvents(ctx context.Context, filter models.AuditFilter, limit int) ([]models.AuditEvent, error) {
+	return as.repo.GetEvents(ctx, filter, limit)
+}
+
+// WithRequestInfo returns context carrying client request metadata
+func WithRequestInfo(ctx context.Context, info models.RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns client request metadata of ctx
+func RequestInfoFromContext(ctx context.Context) models.RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(models.RequestInfo)
+	return info
+}
+
+// auditEvent creates audit event of tenant performed by actor and affecting user, zero user ids are not set
+func auditEvent(eventType string, tenantID uint64, actorID uint64, userID uint64, details map[string]interface{}) *models.AuditEvent {
+	event := &models.AuditEvent{TenantID: tenantID, Type: eventType, Details: details}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+	return event
+}
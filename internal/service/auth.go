@@ -2,47 +2,316 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"github.com/google/uuid"
 	"github.com/rookgm/gophermart/internal/auth"
 	"github.com/rookgm/gophermart/internal/models"
-	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"time"
 )
 
+const (
+	// refreshTokenSize is size of refresh token in bytes
+	refreshTokenSize = 32
+	// dummyPassword is hashed to verify passwords of unknown logins against
+	dummyPassword = "gophermart-dummy-password"
+)
+
+// TokenRepository is interface for interacting with token-related data
+type TokenRepository interface {
+	// CreateRefreshToken inserts new refresh token
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error)
+	// GetRefreshTokenByHash returns refresh token by its hash
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RevokeRefreshToken revokes refresh token which has not been revoked yet
+	RevokeRefreshToken(ctx context.Context, id uint64) error
+	// RevokeRefreshTokenFamily revokes all refresh tokens rotated from the same login
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	// RevokeAccessToken adds access token to revocation list
+	RevokeAccessToken(ctx context.Context, id uuid.UUID, userID uint64, expiresAt time.Time) error
+	// DeleteExpiredRevokedTokens deletes access tokens expired before time from revocation list
+	DeleteExpiredRevokedTokens(ctx context.Context, before time.Time) error
+	// RevokeUserTokens revokes all refresh tokens of user and access tokens issued until now
+	RevokeUserTokens(ctx context.Context, userID uint64) error
+	// IsAccessTokenRevoked checks whether access token has been revoked
+	IsAccessTokenRevoked(ctx context.Context, payload *models.TokenPayload) (bool, error)
+}
+
+// LoginThrottle is interface for limiting failed login attempts
+type LoginThrottle interface {
+	// Allow checks whether login attempt is allowed
+	Allow(ctx context.Context, login string, ip string) error
+	// Failure records failed login attempt and returns lockouts started by it
+	Failure(ctx context.Context, login string, ip string) ([]models.LoginLockout, error)
+	// Success forgets failed attempts of login
+	Success(ctx context.Context, login string) error
+}
+
 // AuthService implements AuthService interface
 type AuthService struct {
-	repo     UserRepository
-	tokenSvc TokenService
+	repo       UserRepository
+	tokenRepo  TokenRepository
+	tokenSvc   TokenService
+	uow        UnitOfWork
+	hasher     PasswordHasher
+	throttle   LoginThrottle
+	audit      AuditRecorder
+	refreshTTL time.Duration
+	// dummyHash is verified for unknown logins, so they take as long as wrong passwords
+	dummyHash string
 }
 
-// NewAuthService creates AuthService instance
-func NewAuthService(repo UserRepository, ts TokenService) *AuthService {
-	return &AuthService{repo: repo, tokenSvc: ts}
+// NewAuthService creates AuthService instance. Issued refresh tokens are valid for refreshTTL.
+// Returns error if hasher can not hash passwords.
+func NewAuthService(repo UserRepository, tokenRepo TokenRepository, ts TokenService, uow UnitOfWork, hasher PasswordHasher, throttle LoginThrottle, audit AuditRecorder, refreshTTL time.Duration) (*AuthService, error) {
+	dummyHash, err := hasher.Hash(dummyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthService{repo: repo, tokenRepo: tokenRepo, tokenSvc: ts, uow: uow, hasher: hasher, throttle: throttle, audit: audit, refreshTTL: refreshTTL, dummyHash: dummyHash}, nil
 }
 
-// Login authenticates registered user
-func (as *AuthService) Login(ctx context.Context, login string, password string) (string, error) {
-	user, err := as.repo.GetUserByLogin(ctx, login)
+// Login authenticates registered user of tenant connected from ip.
+// Returns LockoutError if there were too many failed attempts.
+func (as *AuthService) Login(ctx context.Context, tenantID uint64, login string, password string, ip string) (*models.TokenPair, error) {
+	// the same login of different tenants belongs to different users
+	throttleLogin := strconv.FormatUint(tenantID, 10) + ":" + login
+
+	if err := as.throttle.Allow(ctx, throttleLogin, ip); err != nil {
+		if errors.Is(err, models.ErrLoginLocked) {
+			as.recordLoginFailure(ctx, tenantID, 0, login, "locked")
+		}
+		return nil, err
+	}
+
+	user, err := as.repo.GetUserByLogin(ctx, tenantID, login)
 	if err != nil {
 		if errors.Is(err, models.ErrDataNotFound) {
-			return "", models.ErrInvalidCredentials
+			// response time must not tell whether login exists
+			_ = as.hasher.Verify(password, as.dummyHash)
+			as.recordLoginFailure(ctx, tenantID, 0, login, "unknown_login")
+			return nil, as.loginFailed(ctx, tenantID, 0, throttleLogin, login, ip)
 		}
-		return "", err
+		return nil, err
+	}
+
+	if err := as.hasher.Verify(password, user.Password); err != nil {
+		as.recordLoginFailure(ctx, tenantID, user.ID, login, "wrong_password")
+		return nil, as.loginFailed(ctx, tenantID, user.ID, throttleLogin, login, ip)
 	}
 
-	if err := ComparePassword(password, user.Password); err != nil {
-		return "", models.ErrInvalidCredentials
+	if err := as.throttle.Success(ctx, throttleLogin); err != nil {
+		return nil, err
 	}
 
-	token, err := as.tokenSvc.CreateToken(user)
+	as.rehashPassword(ctx, user, password)
+
+	pair, err := as.CreateSession(ctx, user)
 	if err != nil {
-		return "", auth.ErrTokenCreate
+		return nil, err
+	}
+
+	as.audit.Record(ctx, auditEvent(models.AuditLoginSucceeded, user.TenantID, user.ID, user.ID, nil))
+
+	return pair, nil
+}
+
+// recordLoginFailure records failed login attempt of tenant to audit log. userID is zero if login is unknown.
+func (as *AuthService) recordLoginFailure(ctx context.Context, tenantID uint64, userID uint64, login string, reason string) {
+	as.audit.Record(ctx, auditEvent(models.AuditLoginFailed, tenantID, 0, userID, map[string]interface{}{
+		"login":  login,
+		"reason": reason,
+	}))
+}
+
+// rehashPassword upgrades password hash weaker than current hashing policy. Plaintext password
+// is known only on login, so this is the only moment hash can be upgraded. Failure does not
+// affect login, hash is upgraded on next login then.
+func (as *AuthService) rehashPassword(ctx context.Context, user *models.User, password string) {
+	if !as.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := as.hasher.Hash(password)
+	if err != nil {
+		return
+	}
+
+	// password changed concurrently is not overwritten
+	_ = as.repo.ReplacePassword(ctx, user.ID, user.Password, hashedPassword)
+}
+
+// loginFailed records failed login attempt of tenant user and returns ErrInvalidCredentials.
+// Lockouts started by the attempt are recorded to audit log. userID is zero if login is unknown.
+func (as *AuthService) loginFailed(ctx context.Context, tenantID uint64, userID uint64, throttleLogin string, login string, ip string) error {
+	lockouts, err := as.throttle.Failure(ctx, throttleLogin, ip)
+	if err != nil {
+		return err
+	}
+
+	for _, lockout := range lockouts {
+		as.audit.Record(ctx, auditEvent(models.AuditLoginLocked, tenantID, 0, userID, map[string]interface{}{
+			"login":        login,
+			"key":          lockout.Key,
+			"failures":     lockout.Failures,
+			"locked_until": lockout.LockedUntil,
+		}))
+	}
+
+	return models.ErrInvalidCredentials
+}
+
+// CreateSession issues access token and refresh token of new token family to user
+func (as *AuthService) CreateSession(ctx context.Context, user *models.User) (*models.TokenPair, error) {
+	return as.issueTokens(ctx, as.tokenRepo, user, uuid.New())
+}
+
+// Refresh rotates refresh token of tenant user and issues new access token. Reuse of rotated
+// refresh token revokes the whole token family, since the token is likely to be stolen.
+func (as *AuthService) Refresh(ctx context.Context, tenantID uint64, refreshToken string) (*models.TokenPair, error) {
+	token, err := as.tokenRepo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, models.ErrDataNotFound) {
+			return nil, models.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if token.RevokedAt != nil {
+		if err := as.tokenRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+			return nil, err
+		}
+		as.audit.Record(ctx, auditEvent(models.AuditTokenFamilyRevoked, as.userTenantID(ctx, token.UserID, tenantID), 0, token.UserID, map[string]interface{}{
+			"family_id": token.FamilyID.String(),
+			"reason":    "refresh_token_reuse",
+		}))
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	var pair *models.TokenPair
+	err = as.uow.WithTx(ctx, func(repos TxRepositories) error {
+		if err := repos.Tokens().RevokeRefreshToken(ctx, token.ID); err != nil {
+			if errors.Is(err, models.ErrDataNotFound) {
+				// token has been rotated concurrently
+				return models.ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		// role of user may have changed since login
+		user, err := repos.Users().GetUserByID(ctx, token.UserID)
+		if err != nil {
+			return err
+		}
+
+		// token of another tenant is not rotated
+		if user.TenantID != tenantID {
+			return models.ErrInvalidRefreshToken
+		}
+
+		pair, err = as.issueTokens(ctx, repos.Tokens(), user, token.FamilyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// userTenantID returns tenant of user, or fallback if user can not be read
+func (as *AuthService) userTenantID(ctx context.Context, userID uint64, fallback uint64) uint64 {
+	user, err := as.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fallback
+	}
+	return user.TenantID
+}
+
+// Logout revokes access token and token family of refresh token
+func (as *AuthService) Logout(ctx context.Context, payload *models.TokenPayload, refreshToken string) error {
+	if err := as.tokenRepo.RevokeAccessToken(ctx, payload.ID, payload.UserID, payload.ExpiresAt); err != nil {
+		return err
+	}
+
+	as.audit.Record(ctx, auditEvent(models.AuditLogout, payload.TenantID, payload.UserID, payload.UserID, nil))
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	token, err := as.tokenRepo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, models.ErrDataNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// refresh token of another user is ignored
+	if token.UserID != payload.UserID {
+		return nil
+	}
+
+	return as.tokenRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID)
+}
+
+// IsTokenRevoked checks whether access token has been revoked
+func (as *AuthService) IsTokenRevoked(ctx context.Context, payload *models.TokenPayload) (bool, error) {
+	return as.tokenRepo.IsAccessTokenRevoked(ctx, payload)
+}
+
+// issueTokens creates access token and refresh token belonging to token family
+func (as *AuthService) issueTokens(ctx context.Context, tokenRepo TokenRepository, user *models.User, familyID uuid.UUID) (*models.TokenPair, error) {
+	accessToken, payload, err := as.tokenSvc.CreateToken(user)
+	if err != nil {
+		return nil, auth.ErrTokenCreate
+	}
+
+	refreshToken, err := generateToken()
+	if err != nil {
+		return nil, auth.ErrTokenCreate
+	}
+
+	token, err := tokenRepo.CreateRefreshToken(ctx, &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(as.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  payload.ExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// generateToken returns random URL-safe token
+func generateToken() (string, error) {
+	b := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	return token, nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-// ComparePassword compares a bcrypt hashed password with its possible plaintext equivalent.
-// Returns nil on success, or an error on failure.
-func ComparePassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// hashToken returns hex encoded SHA-256 hash of token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
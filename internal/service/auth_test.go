@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rookgm/gophermart/internal/models"
+	"testing"
+	"time"
+)
+
+// fakeTokenRepository is in-memory refresh token repository
+type fakeTokenRepository struct {
+	TokenRepository
+	tokens []models.RefreshToken
+}
+
+func (fr *fakeTokenRepository) CreateRefreshToken(_ context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	token.ID = uint64(len(fr.tokens) + 1)
+	fr.tokens = append(fr.tokens, *token)
+	return token, nil
+}
+
+func (fr *fakeTokenRepository) GetRefreshTokenByHash(_ context.Context, hash string) (*models.RefreshToken, error) {
+	for _, token := range fr.tokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, models.ErrDataNotFound
+}
+
+func (fr *fakeTokenRepository) RevokeRefreshToken(_ context.Context, id uint64) error {
+	for i := range fr.tokens {
+		if fr.tokens[i].ID == id && fr.tokens[i].RevokedAt == nil {
+			now := time.Now()
+			fr.tokens[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return models.ErrDataNotFound
+}
+
+func (fr *fakeTokenRepository) RevokeRefreshTokenFamily(_ context.Context, familyID uuid.UUID) error {
+	now := time.Now()
+	for i := range fr.tokens {
+		if fr.tokens[i].FamilyID == familyID && fr.tokens[i].RevokedAt == nil {
+			fr.tokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// revoked returns number of revoked tokens
+func (fr *fakeTokenRepository) revoked() int {
+	revoked := 0
+	for _, token := range fr.tokens {
+		if token.RevokedAt != nil {
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// fakeUserRepository returns users by id
+type fakeUserRepository struct {
+	UserRepository
+	users map[uint64]models.User
+}
+
+func (fr *fakeUserRepository) GetUserByID(_ context.Context, id uint64) (*models.User, error) {
+	user, ok := fr.users[id]
+	if !ok {
+		return nil, models.ErrDataNotFound
+	}
+	return &user, nil
+}
+
+func (fr *fakeUserRepository) GetUserByLogin(_ context.Context, tenantID uint64, login string) (*models.User, error) {
+	for _, user := range fr.users {
+		if user.TenantID == tenantID && user.Login == login {
+			return &user, nil
+		}
+	}
+	return nil, models.ErrDataNotFound
+}
+
+// fakeTokenService issues unsigned access tokens
+type fakeTokenService struct {
+	TokenService
+}
+
+func (fakeTokenService) CreateToken(user *models.User) (string, *models.TokenPayload, error) {
+	payload := &models.TokenPayload{ID: uuid.New(), UserID: user.ID, TenantID: user.TenantID, ExpiresAt: time.Now().Add(time.Hour)}
+	return payload.ID.String(), payload, nil
+}
+
+// fakeHasher stores passwords as is
+type fakeHasher struct{}
+
+func (fakeHasher) Hash(password string) (string, error) {
+	return password, nil
+}
+
+func (fakeHasher) Verify(password string, hash string) error {
+	if password != hash {
+		return models.ErrInvalidCredentials
+	}
+	return nil
+}
+
+func (fakeHasher) NeedsRehash(string) bool {
+	return false
+}
+
+// fakeThrottle locks out login on failure after maxFailures failures
+type fakeThrottle struct {
+	maxFailures int
+	failures    int
+}
+
+func (ft *fakeThrottle) Allow(context.Context, string, string) error {
+	return nil
+}
+
+func (ft *fakeThrottle) Failure(_ context.Context, login string, ip string) ([]models.LoginLockout, error) {
+	ft.failures++
+	if ft.failures < ft.maxFailures {
+		return nil, nil
+	}
+	return []models.LoginLockout{{Key: loginThrottleKey(login), IP: ip, Failures: ft.failures, LockedUntil: time.Now().Add(time.Minute)}}, nil
+}
+
+func (ft *fakeThrottle) Success(context.Context, string) error {
+	return nil
+}
+
+// fakeAuditRecorder keeps recorded events
+type fakeAuditRecorder struct {
+	events []models.AuditEvent
+}
+
+func (fa *fakeAuditRecorder) Record(_ context.Context, event *models.AuditEvent) {
+	fa.events = append(fa.events, *event)
+}
+
+func TestAuthServiceRefresh(t *testing.T) {
+	const tenantID = models.DefaultTenantID
+	user := models.User{ID: 1, TenantID: tenantID, Login: "user"}
+
+	tests := []struct {
+		name string
+		// refresh rotates tokens of new session and returns token presented to Refresh
+		refresh func(t *testing.T, as *AuthService, session *models.TokenPair) string
+		// tenantID is tenant token is presented to
+		tenantID uint64
+		wantErr  error
+		// wantFamilyRevoked is whether all tokens of family are revoked
+		wantFamilyRevoked bool
+	}{
+		{
+			name:     "valid token is rotated",
+			refresh:  func(t *testing.T, as *AuthService, session *models.TokenPair) string { return session.RefreshToken },
+			tenantID: tenantID,
+		},
+		{
+			name: "rotated token is rotated again",
+			refresh: func(t *testing.T, as *AuthService, session *models.TokenPair) string {
+				return mustRefresh(t, as, tenantID, session.RefreshToken).RefreshToken
+			},
+			tenantID: tenantID,
+		},
+		{
+			name: "reuse of rotated token revokes token family",
+			refresh: func(t *testing.T, as *AuthService, session *models.TokenPair) string {
+				mustRefresh(t, as, tenantID, session.RefreshToken)
+				return session.RefreshToken
+			},
+			tenantID:          tenantID,
+			wantErr:           models.ErrInvalidRefreshToken,
+			wantFamilyRevoked: true,
+		},
+		{
+			name:     "unknown token is rejected",
+			refresh:  func(t *testing.T, as *AuthService, session *models.TokenPair) string { return "unknown" },
+			tenantID: tenantID,
+			wantErr:  models.ErrInvalidRefreshToken,
+		},
+		{
+			name:     "token of another tenant is not rotated",
+			refresh:  func(t *testing.T, as *AuthService, session *models.TokenPair) string { return session.RefreshToken },
+			tenantID: tenantID + 1,
+			wantErr:  models.ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := &fakeTokenRepository{}
+			users := &fakeUserRepository{users: map[uint64]models.User{user.ID: user}}
+			audit := &fakeAuditRecorder{}
+			uow := fakeUnitOfWork{repos: fakeTxRepositories{users: users, tokens: tokens}}
+			as, err := NewAuthService(users, tokens, fakeTokenService{}, uow, fakeHasher{}, nil, audit, time.Hour)
+			if err != nil {
+				t.Fatalf("NewAuthService() error = %v", err)
+			}
+
+			session, err := as.CreateSession(context.Background(), &user)
+			if err != nil {
+				t.Fatalf("CreateSession() error = %v", err)
+			}
+
+			refreshToken := tt.refresh(t, as, session)
+			audit.events = nil
+
+			pair, err := as.Refresh(context.Background(), tt.tenantID, refreshToken)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Refresh() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (pair.RefreshToken == "" || pair.RefreshToken == refreshToken) {
+				t.Errorf("Refresh() refresh token is not rotated")
+			}
+
+			if tt.wantFamilyRevoked {
+				if got := tokens.revoked(); got != len(tokens.tokens) {
+					t.Errorf("Refresh() revoked %d of %d family tokens", got, len(tokens.tokens))
+				}
+				if len(audit.events) != 1 || audit.events[0].Type != models.AuditTokenFamilyRevoked {
+					t.Errorf("Refresh() recorded %v, want token family revoked event", audit.events)
+				}
+			} else if len(audit.events) != 0 {
+				t.Errorf("Refresh() recorded %v, want no events", audit.events)
+			}
+		})
+	}
+}
+
+// mustRefresh rotates refresh token and fails test on error
+func mustRefresh(t *testing.T, as *AuthService, tenantID uint64, refreshToken string) *models.TokenPair {
+	t.Helper()
+
+	pair, err := as.Refresh(context.Background(), tenantID, refreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	return pair
+}
+
+func TestAuthServiceLoginFailureAudit(t *testing.T) {
+	const tenantID = models.DefaultTenantID + 1
+	user := models.User{ID: 1, TenantID: tenantID, Login: "user", Password: "secret"}
+
+	tests := []struct {
+		name        string
+		login       string
+		maxFailures int
+		wantUserID  *uint64
+		wantTypes   []string
+	}{
+		{
+			name:        "unknown login",
+			login:       "unknown",
+			maxFailures: 2,
+			wantTypes:   []string{models.AuditLoginFailed},
+		},
+		{
+			name:        "wrong password",
+			login:       user.Login,
+			maxFailures: 2,
+			wantUserID:  &user.ID,
+			wantTypes:   []string{models.AuditLoginFailed},
+		},
+		{
+			name:        "unknown login is locked out",
+			login:       "unknown",
+			maxFailures: 1,
+			wantTypes:   []string{models.AuditLoginFailed, models.AuditLoginLocked},
+		},
+		{
+			name:        "wrong password locks out login",
+			login:       user.Login,
+			maxFailures: 1,
+			wantUserID:  &user.ID,
+			wantTypes:   []string{models.AuditLoginFailed, models.AuditLoginLocked},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserRepository{users: map[uint64]models.User{user.ID: user}}
+			audit := &fakeAuditRecorder{}
+			throttle := &fakeThrottle{maxFailures: tt.maxFailures}
+			as, err := NewAuthService(users, &fakeTokenRepository{}, fakeTokenService{}, fakeUnitOfWork{}, fakeHasher{}, throttle, audit, time.Hour)
+			if err != nil {
+				t.Fatalf("NewAuthService() error = %v", err)
+			}
+
+			if _, err := as.Login(context.Background(), tenantID, tt.login, "wrong", "192.0.2.1"); !errors.Is(err, models.ErrInvalidCredentials) {
+				t.Fatalf("Login() error = %v, want %v", err, models.ErrInvalidCredentials)
+			}
+
+			if len(audit.events) != len(tt.wantTypes) {
+				t.Fatalf("Login() recorded %v, want %v", audit.events, tt.wantTypes)
+			}
+			for i, event := range audit.events {
+				if event.Type != tt.wantTypes[i] {
+					t.Errorf("Login() recorded %q, want %q", event.Type, tt.wantTypes[i])
+				}
+				// events of unknown logins are listed by tenant too
+				if event.TenantID != tenantID {
+					t.Errorf("Login() recorded %q of tenant %d, want %d", event.Type, event.TenantID, tenantID)
+				}
+				if (event.UserID == nil) != (tt.wantUserID == nil) || event.UserID != nil && *event.UserID != *tt.wantUserID {
+					t.Errorf("Login() recorded %q of user %v, want %v", event.Type, event.UserID, tt.wantUserID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"strings"
+	"time"
+)
+
+// LedgerRepository is interface for interacting with points ledger
+type LedgerRepository interface {
+	// CreateEntry appends new entry to ledger
+	CreateEntry(ctx context.Context, entry *models.LedgerEntry) (*models.LedgerEntry, error)
+	// GetBalanceByUserID returns user balance calculated from ledger entries
+	GetBalanceByUserID(ctx context.Context, userID uint64) (*models.Balance, error)
+	// GetOpenCreditLots returns user credits which have not expired at time and have unconsumed points, oldest first
+	GetOpenCreditLots(ctx context.Context, userID uint64, at time.Time) ([]models.CreditLot, error)
+	// GetExpiredCreditLots returns user credits which have expired at time and have unconsumed points, oldest first
+	GetExpiredCreditLots(ctx context.Context, userID uint64, at time.Time) ([]models.CreditLot, error)
+	// GetUsersWithExpiredCredits returns ids of users having expired credits with unconsumed points
+	GetUsersWithExpiredCredits(ctx context.Context, at time.Time, limit int) ([]uint64, error)
+	// CreateAllocation records consumption of credit by debit
+	CreateAllocation(ctx context.Context, alloc *models.LedgerAllocation) (*models.LedgerAllocation, error)
+	// CreateAdjustment records manual balance adjustment backed by ledger entry
+	CreateAdjustment(ctx context.Context, adj *models.Adjustment) (*models.Adjustment, error)
+}
+
+// WithdrawalRepository is interface for interacting with withdrawal-related data
+type WithdrawalRepository interface {
+	// CreateWithdrawal inserts new withdrawal
+	CreateWithdrawal(ctx context.Context, withdrawal *models.Withdrawal) (*models.Withdrawal, error)
+	// GetWithdrawalsByUserID returns user withdrawals, newest first
+	GetWithdrawalsByUserID(ctx context.Context, userID uint64) ([]models.Withdrawal, error)
+}
+
+// BalanceService implements BalanceService interface
+type BalanceService struct {
+	ledgerRepo     LedgerRepository
+	withdrawalRepo WithdrawalRepository
+	uow            UnitOfWork
+	validator      OrderNumberValidator
+	audit          AuditRecorder
+	pointsTTL      time.Duration
+	expiringSoon   time.Duration
+}
+
+// NewBalanceService creates new BalanceService instance. Points credited by adjustments
+// expire after pointsTTL, points expiring within expiringSoon period are reported as expiring soon.
+func NewBalanceService(ledgerRepo LedgerRepository, withdrawalRepo WithdrawalRepository, uow UnitOfWork, validator OrderNumberValidator, audit AuditRecorder, pointsTTL time.Duration, expiringSoon time.Duration) *BalanceService {
+	return &BalanceService{ledgerRepo: ledgerRepo, withdrawalRepo: withdrawalRepo, uow: uow, validator: validator, audit: audit, pointsTTL: pointsTTL, expiringSoon: expiringSoon}
+}
+
+// GetUserBalance returns user balance
+func (bs *BalanceService) GetUserBalance(ctx context.Context, userID uint64) (*models.Balance, error) {
+	balance, err := bs.ledgerRepo.GetBalanceByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	// expired points are not available even if expiry job has not moved them yet
+	expired, err := bs.ledgerRepo.GetExpiredCreditLots(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, lot := range expired {
+		balance.Current -= lot.Remaining
+	}
+
+	open, err := bs.ledgerRepo.GetOpenCreditLots(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	soon := now.Add(bs.expiringSoon)
+	for _, lot := range open {
+		if lot.ExpiresAt != nil && !lot.ExpiresAt.After(soon) {
+			balance.ExpiringSoon += lot.Remaining
+		}
+	}
+
+	balance.Current = roundPoints(balance.Current)
+	balance.ExpiringSoon = roundPoints(balance.ExpiringSoon)
+
+	return balance, nil
+}
+
+// Withdraw debits balance of tenant user in favor of order. User is locked for the transaction,
+// so concurrent withdrawals of the same user are serialized and can not overdraw balance.
+func (bs *BalanceService) Withdraw(ctx context.Context, tenantID uint64, withdrawal *models.Withdrawal) (*models.Withdrawal, error) {
+	number, err := bs.validator.Validate(ctx, tenantID, withdrawal.Order)
+	if err != nil {
+		return nil, err
+	}
+	withdrawal.Order = number
+
+	err = bs.uow.WithTx(ctx, func(repos TxRepositories) error {
+		if err := repos.Users().LockUserByID(ctx, withdrawal.UserID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		// expired points can not be withdrawn
+		if err := expireUserPoints(ctx, repos, withdrawal.UserID, now); err != nil {
+			return err
+		}
+
+		balance, err := repos.Ledger().GetBalanceByUserID(ctx, withdrawal.UserID)
+		if err != nil {
+			return err
+		}
+
+		if balance.Current < withdrawal.Sum {
+			return models.ErrInsufficientFunds
+		}
+
+		withdrawal, err = repos.Withdrawals().CreateWithdrawal(ctx, withdrawal)
+		if err != nil {
+			return err
+		}
+
+		// move withdrawn points from user account
+		entry, err := repos.Ledger().CreateEntry(ctx, &models.LedgerEntry{
+			UserID:        withdrawal.UserID,
+			DebitAccount:  models.LedgerAccountUser,
+			CreditAccount: models.LedgerAccountWithdrawal,
+			Amount:        withdrawal.Sum,
+			WithdrawalID:  &withdrawal.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return allocateDebit(ctx, repos, entry, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bs.audit.Record(ctx, auditEvent(models.AuditWithdrawalCreated, tenantID, withdrawal.UserID, withdrawal.UserID, map[string]interface{}{
+		"order": withdrawal.Order,
+		"sum":   withdrawal.Sum,
+	}))
+
+	return withdrawal, nil
+}
+
+// ListUserWithdrawals returns list of user withdrawals, newest first
+func (bs *BalanceService) ListUserWithdrawals(ctx context.Context, userID uint64) ([]models.Withdrawal, error) {
+	withdrawals, err := bs.withdrawalRepo.GetWithdrawalsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(withdrawals) == 0 {
+		return nil, models.ErrDataNotFound
+	}
+
+	return withdrawals, nil
+}
+
+// Adjust applies manual balance adjustment to user of tenant. Reason is mandatory. Negative adjustment
+// can not exceed user balance and consumes credits like withdrawals do.
+func (bs *BalanceService) Adjust(ctx context.Context, tenantID uint64, adj *models.Adjustment) (*models.Adjustment, error) {
+	adj.Amount = roundPoints(adj.Amount)
+	adj.Reason = strings.TrimSpace(adj.Reason)
+
+	var fields []models.FieldError
+	if adj.Amount == 0 {
+		fields = append(fields, models.FieldError{Field: "amount", Code: "required", Message: "amount must not be zero"})
+	}
+	if adj.Reason == "" {
+		fields = append(fields, models.FieldError{Field: "reason", Code: "required", Message: "reason is required"})
+	}
+	if len(fields) > 0 {
+		return nil, &models.ValidationError{Fields: fields}
+	}
+
+	err := bs.uow.WithTx(ctx, func(repos TxRepositories) error {
+		if err := repos.Users().LockUserByID(ctx, adj.UserID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		var entry *models.LedgerEntry
+		if adj.Amount > 0 {
+			entry = &models.LedgerEntry{
+				UserID:        adj.UserID,
+				DebitAccount:  models.LedgerAccountAdjustment,
+				CreditAccount: models.LedgerAccountUser,
+				Amount:        adj.Amount,
+				ExpiresAt:     expiresAt(bs.pointsTTL),
+			}
+		} else {
+			// expired points can not be debited
+			if err := expireUserPoints(ctx, repos, adj.UserID, now); err != nil {
+				return err
+			}
+
+			balance, err := repos.Ledger().GetBalanceByUserID(ctx, adj.UserID)
+			if err != nil {
+				return err
+			}
+
+			if balance.Current < -adj.Amount {
+				return models.ErrInsufficientFunds
+			}
+
+			entry = &models.LedgerEntry{
+				UserID:        adj.UserID,
+				DebitAccount:  models.LedgerAccountUser,
+				CreditAccount: models.LedgerAccountAdjustment,
+				Amount:        -adj.Amount,
+			}
+		}
+
+		entry, err := repos.Ledger().CreateEntry(ctx, entry)
+		if err != nil {
+			return err
+		}
+
+		if adj.Amount < 0 {
+			if err := allocateDebit(ctx, repos, entry, now); err != nil {
+				return err
+			}
+		}
+
+		adj.EntryID = entry.ID
+		adj, err = repos.Ledger().CreateAdjustment(ctx, adj)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bs.audit.Record(ctx, auditEvent(models.AuditBalanceAdjusted, tenantID, adj.ActorID, adj.UserID, map[string]interface{}{
+		"adjustment_id": adj.ID,
+		"amount":        adj.Amount,
+		"reason":        adj.Reason,
+	}))
+
+	return adj, nil
+}
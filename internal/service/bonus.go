@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"time"
+)
+
+// BonusRuleRepository is interface for interacting with bonus rules
+type BonusRuleRepository interface {
+	// GetActiveRules returns rules of kinds which are active at time
+	GetActiveRules(ctx context.Context, kinds []string, at time.Time) ([]models.BonusRule, error)
+}
+
+// BonusRepository is interface for interacting with credited bonuses
+type BonusRepository interface {
+	// GetBonusesByUserID returns bonuses credited to user, newest first
+	GetBonusesByUserID(ctx context.Context, userID uint64) ([]models.Bonus, error)
+}
+
+// BonusService implements BonusService interface. Bonuses are credited to user account
+// as separate ledger entries referencing the rule, apart from accrual system points.
+type BonusService struct {
+	repo      BonusRepository
+	pointsTTL time.Duration
+}
+
+// NewBonusService creates new BonusService instance. Credited bonus points expire after pointsTTL, zero means never.
+func NewBonusService(repo BonusRepository, pointsTTL time.Duration) *BonusService {
+	return &BonusService{repo: repo, pointsTTL: pointsTTL}
+}
+
+// ApplySignupBonuses credits signup bonuses to registered user
+func (bs *BonusService) ApplySignupBonuses(ctx context.Context, repos TxRepositories, user *models.User) error {
+	rules, err := repos.BonusRules().GetActiveRules(ctx, []string{models.BonusRuleSignup}, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if err := bs.credit(ctx, repos, rule, user.ID, nil, rule.Amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ApplyOrderBonuses credits bonuses for processed order. Rules are evaluated at order upload time.
+func (bs *BonusService) ApplyOrderBonuses(ctx context.Context, repos TxRepositories, order *models.Order) error {
+	rules, err := repos.BonusRules().GetActiveRules(ctx, []string{models.BonusRuleFirstOrder, models.BonusRulePeriod}, order.UploadedAt)
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	// lock user, so concurrently processed orders can not both be the first one
+	if err := repos.Users().LockUserByID(ctx, order.UserID); err != nil {
+		return err
+	}
+
+	processed, err := repos.Orders().CountOrdersByStatus(ctx, order.UserID, models.OrderStatusProcessed)
+	if err != nil {
+		return err
+	}
+
+	var accrual float64
+	if order.Accrual != nil {
+		accrual = *order.Accrual
+	}
+
+	for _, rule := range rules {
+		if rule.Kind == models.BonusRuleFirstOrder && processed != 1 {
+			continue
+		}
+
+		amount := accrual*(rule.Multiplier-1) + rule.Amount
+		if err := bs.credit(ctx, repos, rule, order.UserID, &order.ID, amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListUserBonuses returns bonuses credited to user
+func (bs *BonusService) ListUserBonuses(ctx context.Context, userID uint64) ([]models.Bonus, error) {
+	bonuses, err := bs.repo.GetBonusesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bonuses) == 0 {
+		return nil, models.ErrDataNotFound
+	}
+
+	return bonuses, nil
+}
+
+// credit moves bonus points to user account
+func (bs *BonusService) credit(ctx context.Context, repos TxRepositories, rule models.BonusRule, userID uint64, orderID *uint64, amount float64) error {
+	amount = roundPoints(amount)
+	if amount <= 0 {
+		return nil
+	}
+
+	_, err := repos.Ledger().CreateEntry(ctx, &models.LedgerEntry{
+		UserID:        userID,
+		DebitAccount:  models.LedgerAccountBonus,
+		CreditAccount: models.LedgerAccountUser,
+		Amount:        amount,
+		OrderID:       orderID,
+		RuleID:        &rule.ID,
+		ExpiresAt:     expiresAt(bs.pointsTTL),
+	})
+
+	return err
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"math"
+	"time"
+)
+
+// expiredUsersLimit is max number of users whose points are expired per batch
+const expiredUsersLimit = 100
+
+// ExpiryService implements ExpiryService interface
+type ExpiryService struct {
+	ledgerRepo LedgerRepository
+	tokenRepo  TokenRepository
+	uow        UnitOfWork
+}
+
+// NewExpiryService creates new ExpiryService instance
+func NewExpiryService(ledgerRepo LedgerRepository, tokenRepo TokenRepository, uow UnitOfWork) *ExpiryService {
+	return &ExpiryService{ledgerRepo: ledgerRepo, tokenRepo: tokenRepo, uow: uow}
+}
+
+// PruneRevokedTokens deletes expired access tokens from revocation list,
+// they are rejected by expiration anyway
+func (es *ExpiryService) PruneRevokedTokens(ctx context.Context) error {
+	return es.tokenRepo.DeleteExpiredRevokedTokens(ctx, time.Now())
+}
+
+// ExpirePoints moves unconsumed points of expired credits from user accounts
+func (es *ExpiryService) ExpirePoints(ctx context.Context) error {
+	now := time.Now()
+
+	for {
+		userIDs, err := es.ledgerRepo.GetUsersWithExpiredCredits(ctx, now, expiredUsersLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range userIDs {
+			err := es.uow.WithTx(ctx, func(repos TxRepositories) error {
+				// lock user, so expiration does not race with withdrawals
+				if err := repos.Users().LockUserByID(ctx, userID); err != nil {
+					return err
+				}
+
+				return expireUserPoints(ctx, repos, userID, now)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(userIDs) < expiredUsersLimit {
+			return nil
+		}
+	}
+}
+
+// expireUserPoints moves unconsumed points of user credits expired at time to expired account.
+// User must be locked by the caller.
+func expireUserPoints(ctx context.Context, repos TxRepositories, userID uint64, at time.Time) error {
+	lots, err := repos.Ledger().GetExpiredCreditLots(ctx, userID, at)
+	if err != nil {
+		return err
+	}
+
+	for _, lot := range lots {
+		entry, err := repos.Ledger().CreateEntry(ctx, &models.LedgerEntry{
+			UserID:        userID,
+			DebitAccount:  models.LedgerAccountUser,
+			CreditAccount: models.LedgerAccountExpired,
+			Amount:        lot.Remaining,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = repos.Ledger().CreateAllocation(ctx, &models.LedgerAllocation{
+			DebitEntryID:  entry.ID,
+			CreditEntryID: lot.EntryID,
+			Amount:        lot.Remaining,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// allocateDebit consumes user credits by debit in FIFO order. User must be locked by the caller.
+func allocateDebit(ctx context.Context, repos TxRepositories, debit *models.LedgerEntry, at time.Time) error {
+	lots, err := repos.Ledger().GetOpenCreditLots(ctx, debit.UserID, at)
+	if err != nil {
+		return err
+	}
+
+	left := debit.Amount
+	for _, lot := range lots {
+		if left <= 0 {
+			break
+		}
+
+		amount := math.Min(left, lot.Remaining)
+		_, err = repos.Ledger().CreateAllocation(ctx, &models.LedgerAllocation{
+			DebitEntryID:  debit.ID,
+			CreditEntryID: lot.EntryID,
+			Amount:        amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		left = roundPoints(left - amount)
+	}
+
+	return nil
+}
+
+// expiresAt returns expiration time of points credited now, or nil if points never expire
+func expiresAt(ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+
+	t := time.Now().Add(ttl)
+	return &t
+}
+
+// roundPoints rounds points to two decimal places kept by ledger
+func roundPoints(points float64) float64 {
+	return math.Round(points*100) / 100
+}
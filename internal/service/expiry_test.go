@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeLedger is in-memory ledger repository keeping entries and allocations
+type fakeLedger struct {
+	LedgerRepository
+	entries     []models.LedgerEntry
+	allocations []models.LedgerAllocation
+}
+
+func (fl *fakeLedger) CreateEntry(_ context.Context, entry *models.LedgerEntry) (*models.LedgerEntry, error) {
+	entry.ID = uint64(len(fl.entries) + 1)
+	fl.entries = append(fl.entries, *entry)
+	return entry, nil
+}
+
+func (fl *fakeLedger) CreateAllocation(_ context.Context, alloc *models.LedgerAllocation) (*models.LedgerAllocation, error) {
+	alloc.ID = uint64(len(fl.allocations) + 1)
+	fl.allocations = append(fl.allocations, *alloc)
+	return alloc, nil
+}
+
+func (fl *fakeLedger) GetOpenCreditLots(_ context.Context, userID uint64, at time.Time) ([]models.CreditLot, error) {
+	return fl.creditLots(userID, func(lot models.CreditLot) bool {
+		return lot.ExpiresAt == nil || lot.ExpiresAt.After(at)
+	}), nil
+}
+
+func (fl *fakeLedger) GetExpiredCreditLots(_ context.Context, userID uint64, at time.Time) ([]models.CreditLot, error) {
+	return fl.creditLots(userID, func(lot models.CreditLot) bool {
+		return lot.ExpiresAt != nil && !lot.ExpiresAt.After(at)
+	}), nil
+}
+
+// creditLots returns user credits with unconsumed points matching filter, oldest first
+func (fl *fakeLedger) creditLots(userID uint64, match func(lot models.CreditLot) bool) []models.CreditLot {
+	var lots []models.CreditLot
+	for _, entry := range fl.entries {
+		if entry.UserID != userID || entry.CreditAccount != models.LedgerAccountUser {
+			continue
+		}
+
+		lot := models.CreditLot{
+			EntryID:   entry.ID,
+			UserID:    entry.UserID,
+			Amount:    entry.Amount,
+			Remaining: entry.Amount,
+			ExpiresAt: entry.ExpiresAt,
+			CreatedAt: entry.CreatedAt,
+		}
+		for _, alloc := range fl.allocations {
+			if alloc.CreditEntryID == entry.ID {
+				lot.Remaining = roundPoints(lot.Remaining - alloc.Amount)
+			}
+		}
+
+		if lot.Remaining > 0 && match(lot) {
+			lots = append(lots, lot)
+		}
+	}
+
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lots[i].CreatedAt.Before(lots[j].CreatedAt)
+	})
+	return lots
+}
+
+// testCredit is credit of user account created before debit
+type testCredit struct {
+	amount    float64
+	consumed  float64
+	expiresIn time.Duration
+}
+
+// newTestLedger returns ledger with user credits created a minute apart, oldest first.
+// Credit with zero expiresIn never expires.
+func newTestLedger(t *testing.T, userID uint64, now time.Time, credits []testCredit) *fakeLedger {
+	t.Helper()
+
+	ledger := &fakeLedger{}
+	for i, credit := range credits {
+		entry := &models.LedgerEntry{
+			UserID:        userID,
+			DebitAccount:  models.LedgerAccountAccrual,
+			CreditAccount: models.LedgerAccountUser,
+			Amount:        credit.amount,
+			CreatedAt:     now.Add(time.Duration(i-len(credits)) * time.Minute),
+		}
+		if credit.expiresIn != 0 {
+			expiresAt := now.Add(credit.expiresIn)
+			entry.ExpiresAt = &expiresAt
+		}
+		entry, _ = ledger.CreateEntry(context.Background(), entry)
+
+		if credit.consumed > 0 {
+			_, _ = ledger.CreateAllocation(context.Background(), &models.LedgerAllocation{
+				CreditEntryID: entry.ID,
+				Amount:        credit.consumed,
+			})
+		}
+	}
+	return ledger
+}
+
+// allocated returns credit entry ids and amounts allocated to debit
+func (fl *fakeLedger) allocated(debitID uint64) map[uint64]float64 {
+	allocated := map[uint64]float64{}
+	for _, alloc := range fl.allocations {
+		if alloc.DebitEntryID == debitID {
+			allocated[alloc.CreditEntryID] += alloc.Amount
+		}
+	}
+	return allocated
+}
+
+func TestAllocateDebit(t *testing.T) {
+	const userID = 1
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		credits []testCredit
+		debit   float64
+		want    map[uint64]float64
+	}{
+		{
+			name:    "single credit is consumed partially",
+			credits: []testCredit{{amount: 100}},
+			debit:   30,
+			want:    map[uint64]float64{1: 30},
+		},
+		{
+			name:    "oldest credits are consumed first",
+			credits: []testCredit{{amount: 50}, {amount: 30}, {amount: 20}},
+			debit:   70,
+			want:    map[uint64]float64{1: 50, 2: 20},
+		},
+		{
+			name:    "consumed credit is skipped",
+			credits: []testCredit{{amount: 50, consumed: 50}, {amount: 30, consumed: 10}},
+			debit:   15,
+			want:    map[uint64]float64{2: 15},
+		},
+		{
+			name:    "expired credit is skipped",
+			credits: []testCredit{{amount: 50, expiresIn: -time.Hour}, {amount: 30, expiresIn: time.Hour}},
+			debit:   20,
+			want:    map[uint64]float64{2: 20},
+		},
+		{
+			name:    "fractional points do not leave remainder",
+			credits: []testCredit{{amount: 0.1}, {amount: 0.2}, {amount: 5}},
+			debit:   0.3,
+			want:    map[uint64]float64{1: 0.1, 2: 0.2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := newTestLedger(t, userID, now, tt.credits)
+			repos := fakeTxRepositories{ledger: ledger}
+
+			debit, _ := ledger.CreateEntry(context.Background(), &models.LedgerEntry{
+				UserID:        userID,
+				DebitAccount:  models.LedgerAccountUser,
+				CreditAccount: models.LedgerAccountWithdrawal,
+				Amount:        tt.debit,
+				CreatedAt:     now,
+			})
+
+			if err := allocateDebit(context.Background(), repos, debit, now); err != nil {
+				t.Fatalf("allocateDebit() error = %v", err)
+			}
+
+			if got := ledger.allocated(debit.ID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocateDebit() allocated %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpireUserPoints(t *testing.T) {
+	const userID = 1
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		credits []testCredit
+		// want is expired amount of each credit entry id
+		want map[uint64]float64
+	}{
+		{
+			name:    "unconsumed points of expired credit expire",
+			credits: []testCredit{{amount: 100, consumed: 30, expiresIn: -time.Hour}},
+			want:    map[uint64]float64{1: 70},
+		},
+		{
+			name:    "credit expiring later is kept",
+			credits: []testCredit{{amount: 100, expiresIn: -time.Hour}, {amount: 50, expiresIn: time.Hour}},
+			want:    map[uint64]float64{1: 100},
+		},
+		{
+			name:    "credit never expiring is kept",
+			credits: []testCredit{{amount: 100}},
+			want:    map[uint64]float64{},
+		},
+		{
+			name:    "consumed credit does not expire",
+			credits: []testCredit{{amount: 100, consumed: 100, expiresIn: -time.Hour}},
+			want:    map[uint64]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := newTestLedger(t, userID, now, tt.credits)
+			repos := fakeTxRepositories{ledger: ledger}
+			credits := len(ledger.entries)
+
+			if err := expireUserPoints(context.Background(), repos, userID, now); err != nil {
+				t.Fatalf("expireUserPoints() error = %v", err)
+			}
+
+			got := map[uint64]float64{}
+			for _, entry := range ledger.entries[credits:] {
+				if entry.DebitAccount != models.LedgerAccountUser || entry.CreditAccount != models.LedgerAccountExpired {
+					t.Fatalf("expireUserPoints() created entry %s -> %s", entry.DebitAccount, entry.CreditAccount)
+				}
+
+				allocated := ledger.allocated(entry.ID)
+				if len(allocated) != 1 {
+					t.Fatalf("expireUserPoints() allocated %v, want single credit", allocated)
+				}
+				for creditID, amount := range allocated {
+					if amount != entry.Amount {
+						t.Errorf("expireUserPoints() allocated %v of entry amount %v", amount, entry.Amount)
+					}
+					got[creditID] = amount
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expireUserPoints() expired %v, want %v", got, tt.want)
+			}
+
+			// points left after expiry are never expired again
+			if lots, _ := ledger.GetExpiredCreditLots(context.Background(), userID, now); len(lots) != 0 {
+				t.Errorf("expireUserPoints() left expired lots %v", lots)
+			}
+		})
+	}
+}
@@ -3,55 +3,155 @@ package service
 import (
 	"context"
 	"errors"
-	"github.com/phedde/luhn-algorithm"
 	"github.com/rookgm/gophermart/internal/models"
-	"strconv"
 )
 
 // OrderRepository is interface for interacting with order-related data
 type OrderRepository interface {
 	// CreateOrder inserts new order to database
 	CreateOrder(ctx context.Context, order *models.Order) (*models.Order, error)
-	// GetOrdersByUserID gets user orders
-	GetOrdersByUserID(ctx context.Context, userID uint64) ([]models.Order, error)
+	// CreateOrders inserts new orders of user and returns upload result of each distinct number
+	CreateOrders(ctx context.Context, tenantID uint64, userID uint64, numbers []string, status string) ([]models.OrderUpload, error)
+	// GetOrdersByUserID gets at most limit user orders matching filter, newest first, or all if limit is zero
+	GetOrdersByUserID(ctx context.Context, userID uint64, filter models.OrderFilter, limit int) ([]models.Order, error)
+	// UpdateOrderStatus updates status and accrual of order which status is not final and differs
+	UpdateOrderStatus(ctx context.Context, id uint64, status string, accrual *float64) (*models.Order, error)
+	// CountOrdersByStatus returns number of user orders having status
+	CountOrdersByStatus(ctx context.Context, userID uint64, status string) (int, error)
+	// RequeueOrder returns order of tenant which has not been processed to accrual queue
+	RequeueOrder(ctx context.Context, tenantID uint64, number string) (*models.Order, error)
+}
+
+// OrderNumberValidator is interface for validating order numbers
+type OrderNumberValidator interface {
+	// Validate checks order number of tenant and returns it normalized.
+	// Returns error wrapping ErrInvalidOrderID if number is invalid.
+	Validate(ctx context.Context, tenantID uint64, number string) (string, error)
 }
 
 // OrderService implements OrderService interface
 type OrderService struct {
-	repo OrderRepository
+	repo      OrderRepository
+	validator OrderNumberValidator
+	audit     AuditRecorder
 }
 
 // NewOrderService creates new NewOrderService instance
-func NewOrderService(repo OrderRepository) *OrderService {
-	return &OrderService{repo: repo}
+func NewOrderService(repo OrderRepository, validator OrderNumberValidator, audit AuditRecorder) *OrderService {
+	return &OrderService{repo: repo, validator: validator, audit: audit}
 }
 
-// Upload uploads user order
+// Upload uploads user order, order number is unique within order.TenantID
 func (os *OrderService) Upload(ctx context.Context, order *models.Order) (*models.Order, error) {
-	num, err := strconv.ParseInt(order.Number, 10, 64)
+	number, err := os.validator.Validate(ctx, order.TenantID, order.Number)
 	if err != nil {
 		return nil, err
 	}
-	// check order id using Luhn algorithm
-	if ok := luhn.IsValid(num); !ok {
-		return nil, models.ErrInvalidOrderID
-	}
+
+	order.Number = number
 
 	// set order status
 	order.Status = models.OrderStatusNew
 
 	order, err = os.repo.CreateOrder(ctx, order)
 	if err != nil {
-		if errors.Is(err, models.ErrConflictData) {
+		return nil, err
+	}
+
+	os.audit.Record(ctx, auditEvent(models.AuditOrderUploaded, order.TenantID, order.UserID, order.UserID, map[string]interface{}{
+		"order": order.Number,
+	}))
+
+	return order, nil
+}
+
+// UploadBatch uploads orders of tenant user and returns upload result of each number in the same order.
+// Numbers are validated like by Upload, valid ones are inserted at once. Repeated number gets the same result.
+func (os *OrderService) UploadBatch(ctx context.Context, tenantID uint64, userID uint64, numbers []string) ([]models.OrderUpload, error) {
+	uploads := make([]models.OrderUpload, len(numbers))
+	valid := make([]string, 0, len(numbers))
+
+	for i, number := range numbers {
+		normalized, err := os.validator.Validate(ctx, tenantID, number)
+		if err != nil {
+			if !errors.Is(err, models.ErrInvalidOrderID) {
+				return nil, err
+			}
+			uploads[i] = models.OrderUpload{Number: number, Result: models.OrderUploadInvalid}
+			continue
+		}
+		uploads[i] = models.OrderUpload{Number: normalized}
+		valid = append(valid, normalized)
+	}
+
+	if len(valid) == 0 {
+		return uploads, nil
+	}
+
+	created, err := os.repo.CreateOrders(ctx, tenantID, userID, valid, models.OrderStatusNew)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]string, len(created))
+	for _, upload := range created {
+		results[upload.Number] = upload.Result
+
+		if upload.Result == models.OrderUploadAccepted {
+			os.audit.Record(ctx, auditEvent(models.AuditOrderUploaded, tenantID, userID, userID, map[string]interface{}{
+				"order": upload.Number,
+				"batch": true,
+			}))
+		}
+	}
+
+	for i := range uploads {
+		if uploads[i].Result == "" {
+			uploads[i].Result = results[uploads[i].Number]
+		}
+	}
+
+	return uploads, nil
+}
+
+// ListUserOrders returns page of at most limit user orders matching filter, newest first.
+// All matching orders are returned on single page if limit is zero.
+func (os *OrderService) ListUserOrders(ctx context.Context, userID uint64, filter models.OrderFilter, limit int) (*models.OrderPage, error) {
+	if limit <= 0 {
+		orders, err := os.repo.GetOrdersByUserID(ctx, userID, filter, 0)
+		if err != nil {
 			return nil, err
 		}
+		return &models.OrderPage{Orders: orders}, nil
+	}
+
+	// one more order is fetched to find out whether there is next page
+	orders, err := os.repo.GetOrdersByUserID(ctx, userID, filter, limit+1)
+	if err != nil {
 		return nil, err
 	}
 
-	return order, nil
+	page := &models.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		last := page.Orders[limit-1]
+		page.Next = &models.OrderCursor{UploadedAt: last.UploadedAt, ID: last.ID}
+	}
+
+	return page, nil
 }
 
-// ListUserOrders returns list of user orders
-func (os *OrderService) ListUserOrders(ctx context.Context, userID uint64) ([]models.Order, error) {
-	return os.repo.GetOrdersByUserID(ctx, userID)
+// Requeue returns order of tenant to accrual queue, so accrual is requested again.
+// Returns ErrOrderProcessed if accrual of order has already been credited.
+func (os *OrderService) Requeue(ctx context.Context, actorID uint64, tenantID uint64, number string) (*models.Order, error) {
+	order, err := os.repo.RequeueOrder(ctx, tenantID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	os.audit.Record(ctx, auditEvent(models.AuditOrderRequeued, tenantID, actorID, order.UserID, map[string]interface{}{
+		"order": order.Number,
+	}))
+
+	return order, nil
 }
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"time"
+)
+
+// PasswordResetRepository is interface for interacting with password reset tokens
+type PasswordResetRepository interface {
+	// CreatePasswordResetToken inserts new password reset token
+	CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) (*models.PasswordResetToken, error)
+	// GetPasswordResetTokenByHash returns password reset token by its hash
+	GetPasswordResetTokenByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+	// UsePasswordResetToken marks token which has not been used yet as used
+	UsePasswordResetToken(ctx context.Context, id uint64) error
+	// UsePasswordResetTokens marks all unused tokens of user as used
+	UsePasswordResetTokens(ctx context.Context, userID uint64) error
+}
+
+// PasswordHasher is interface for hashing and verifying passwords
+type PasswordHasher interface {
+	// Hash returns hash of password
+	Hash(password string) (string, error)
+	// Verify checks whether password matches hash
+	Verify(password string, hash string) error
+	// NeedsRehash checks whether hash is weaker than current hashing policy
+	NeedsRehash(hash string) bool
+}
+
+// PasswordPolicy is interface for validating new passwords
+type PasswordPolicy interface {
+	// ValidatePassword validates new password of user
+	ValidatePassword(password string, login string) error
+}
+
+// ResetNotifier is interface for delivering password reset tokens to users
+type ResetNotifier interface {
+	// NotifyPasswordReset sends password reset token to user
+	NotifyPasswordReset(ctx context.Context, user *models.User, token string, expiresAt time.Time) error
+}
+
+// PasswordService implements password change and reset
+type PasswordService struct {
+	userRepo  UserRepository
+	resetRepo PasswordResetRepository
+	uow       UnitOfWork
+	hasher    PasswordHasher
+	policy    PasswordPolicy
+	notifier  ResetNotifier
+	audit     AuditRecorder
+	resetTTL  time.Duration
+}
+
+// NewPasswordService creates PasswordService instance. Reset tokens are valid for resetTTL.
+// Password reset is disabled if notifier is nil.
+func NewPasswordService(userRepo UserRepository, resetRepo PasswordResetRepository, uow UnitOfWork, hasher PasswordHasher, policy PasswordPolicy, notifier ResetNotifier, audit AuditRecorder, resetTTL time.Duration) *PasswordService {
+	return &PasswordService{
+		userRepo:  userRepo,
+		resetRepo: resetRepo,
+		uow:       uow,
+		hasher:    hasher,
+		policy:    policy,
+		notifier:  notifier,
+		audit:     audit,
+		resetTTL:  resetTTL,
+	}
+}
+
+// ChangePassword changes password of user knowing current password and revokes all user sessions
+func (ps *PasswordService) ChangePassword(ctx context.Context, userID uint64, currentPassword string, newPassword string) error {
+	user, err := ps.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := ps.hasher.Verify(currentPassword, user.Password); err != nil {
+		return models.ErrInvalidCredentials
+	}
+
+	if err := ps.policy.ValidatePassword(newPassword, user.Login); err != nil {
+		return err
+	}
+
+	hashedPassword, err := ps.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = ps.uow.WithTx(ctx, func(repos TxRepositories) error {
+		return setPassword(ctx, repos, user.ID, hashedPassword)
+	})
+	if err != nil {
+		return err
+	}
+
+	ps.audit.Record(ctx, auditEvent(models.AuditPasswordChanged, user.TenantID, user.ID, user.ID, nil))
+
+	return nil
+}
+
+// RequestReset issues password reset token and sends it to user of tenant.
+// Unknown login is not reported, so registered logins can not be discovered.
+func (ps *PasswordService) RequestReset(ctx context.Context, tenantID uint64, login string) error {
+	if ps.notifier == nil {
+		return models.ErrPasswordResetDisabled
+	}
+
+	user, err := ps.userRepo.GetUserByLogin(ctx, tenantID, login)
+	if err != nil {
+		if errors.Is(err, models.ErrDataNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	resetToken, err := ps.resetRepo.CreatePasswordResetToken(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ps.resetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return ps.notifier.NotifyPasswordReset(ctx, user, token, resetToken.ExpiresAt)
+}
+
+// ResetPassword sets new password of user by reset token and revokes all user sessions.
+// Token can be used only once.
+func (ps *PasswordService) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	resetToken, err := ps.resetRepo.GetPasswordResetTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, models.ErrDataNotFound) {
+			return models.ErrInvalidResetToken
+		}
+		return err
+	}
+
+	if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return models.ErrInvalidResetToken
+	}
+
+	user, err := ps.userRepo.GetUserByID(ctx, resetToken.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := ps.policy.ValidatePassword(newPassword, user.Login); err != nil {
+		return err
+	}
+
+	hashedPassword, err := ps.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = ps.uow.WithTx(ctx, func(repos TxRepositories) error {
+		if err := repos.PasswordResets().UsePasswordResetToken(ctx, resetToken.ID); err != nil {
+			if errors.Is(err, models.ErrDataNotFound) {
+				// token has been used concurrently
+				return models.ErrInvalidResetToken
+			}
+			return err
+		}
+
+		return setPassword(ctx, repos, user.ID, hashedPassword)
+	})
+	if err != nil {
+		return err
+	}
+
+	ps.audit.Record(ctx, auditEvent(models.AuditPasswordReset, user.TenantID, user.ID, user.ID, nil))
+
+	return nil
+}
+
+// setPassword replaces password hash of user, revokes user sessions
+// and invalidates pending reset tokens
+func setPassword(ctx context.Context, repos TxRepositories, userID uint64, hashedPassword string) error {
+	if err := repos.Users().UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return err
+	}
+
+	if err := repos.Tokens().RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	return repos.PasswordResets().UsePasswordResetTokens(ctx, userID)
+}
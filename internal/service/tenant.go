@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"github.com/rookgm/gophermart/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tenantCacheTTL is period tenants are cached for, so tenants added by hand are picked up without restart
+const tenantCacheTTL = time.Minute
+
+// TenantRepository is interface for interacting with tenant-related data
+type TenantRepository interface {
+	// GetTenants returns all tenants
+	GetTenants(ctx context.Context) ([]models.Tenant, error)
+}
+
+// TenantService resolves tenants of requests. Tenants are few and rarely change,
+// so they are cached instead of being read on every request.
+type TenantService struct {
+	repo     TenantRepository
+	mu       sync.Mutex
+	tenants  []models.Tenant
+	loadedAt time.Time
+}
+
+// NewTenantService creates TenantService instance
+func NewTenantService(repo TenantRepository) *TenantService {
+	return &TenantService{repo: repo}
+}
+
+// ListTenants returns all tenants
+func (ts *TenantService) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.tenants != nil && time.Since(ts.loadedAt) < tenantCacheTTL {
+		return ts.tenants, nil
+	}
+
+	tenants, err := ts.repo.GetTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ts.tenants = tenants
+	ts.loadedAt = time.Now()
+
+	return tenants, nil
+}
+
+// Resolve returns tenant by slug if it is set, otherwise by host. Request to unknown host
+// is served by default tenant. Returns ErrDataNotFound if there is no tenant with slug.
+func (ts *TenantService) Resolve(ctx context.Context, slug string, host string) (*models.Tenant, error) {
+	tenants, err := ts.ListTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var match func(tenant models.Tenant) bool
+	switch {
+	case slug != "":
+		match = func(tenant models.Tenant) bool { return tenant.Slug == slug }
+	case host != "":
+		match = func(tenant models.Tenant) bool { return strings.EqualFold(tenant.Host, host) }
+	}
+
+	// cached tenants are shared, so copy is returned
+	var defaultTenant *models.Tenant
+	for _, tenant := range tenants {
+		if match != nil && match(tenant) {
+			return &tenant, nil
+		}
+		if tenant.ID == models.DefaultTenantID {
+			defaultTenant = &tenant
+		}
+	}
+
+	if slug != "" || defaultTenant == nil {
+		return nil, models.ErrDataNotFound
+	}
+
+	return defaultTenant, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/rookgm/gophermart/internal/models"
+	"time"
+)
+
+const (
+	// loginDelayBase is delay of login after first failed attempt, it doubles with each failure
+	loginDelayBase = 100 * time.Millisecond
+	// loginDelayMax limits progressive delay of login
+	loginDelayMax = 3 * time.Second
+)
+
+// LoginAttemptRepository is interface for interacting with failed login attempts
+type LoginAttemptRepository interface {
+	// RecordLoginFailure counts failed attempt of key. Failures older than window are not counted.
+	RecordLoginFailure(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error)
+	// GetLoginAttempts returns failed attempts of key
+	GetLoginAttempts(ctx context.Context, key string) (*models.LoginAttempts, error)
+	// LockLogin locks key out until given time
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	// ResetLoginAttempts forgets failed attempts of key
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
+
+// LockoutRepository is interface for recording lockouts
+type LockoutRepository interface {
+	// CreateLockout inserts lockout audit record
+	CreateLockout(ctx context.Context, lockout *models.LoginLockout) (*models.LoginLockout, error)
+}
+
+// ThrottleService limits failed login attempts per login and per client IP.
+// Each failure delays next attempt progressively, and after maxFailures within
+// lockout period the login or IP is locked out for lockout period.
+type ThrottleService struct {
+	repo             LoginAttemptRepository
+	lockoutRepo      LockoutRepository
+	maxLoginFailures int
+	maxIPFailures    int
+	lockout          time.Duration
+}
+
+// NewThrottleService creates ThrottleService instance
+func NewThrottleService(repo LoginAttemptRepository, lockoutRepo LockoutRepository, maxLoginFailures int, maxIPFailures int, lockout time.Duration) *ThrottleService {
+	return &ThrottleService{
+		repo:             repo,
+		lockoutRepo:      lockoutRepo,
+		maxLoginFailures: maxLoginFailures,
+		maxIPFailures:    maxIPFailures,
+		lockout:          lockout,
+	}
+}
+
+// Allow checks whether login attempt is allowed and delays it according to previous failures.
+// Returns LockoutError if login or IP is locked out.
+func (ts *ThrottleService) Allow(ctx context.Context, login string, ip string) error {
+	var failures int
+	for _, key := range throttleKeys(login, ip) {
+		attempts, err := ts.repo.GetLoginAttempts(ctx, key)
+		if err != nil {
+			if errors.Is(err, models.ErrDataNotFound) {
+				continue
+			}
+			return err
+		}
+
+		if attempts.LockedUntil != nil {
+			if retryAfter := time.Until(*attempts.LockedUntil); retryAfter > 0 {
+				return &models.LockoutError{RetryAfter: retryAfter}
+			}
+		}
+
+		// forgotten failures do not delay
+		if time.Since(attempts.LastFailureAt) > ts.lockout {
+			continue
+		}
+		failures = max(failures, attempts.Failures)
+	}
+
+	if failures == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(loginDelay(failures))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Failure records failed login attempt and locks out login or IP exceeding limit.
+// Returns lockouts started by this attempt.
+func (ts *ThrottleService) Failure(ctx context.Context, login string, ip string) ([]models.LoginLockout, error) {
+	var lockouts []models.LoginLockout
+
+	limits := []int{ts.maxLoginFailures, ts.maxIPFailures}
+	for i, key := range throttleKeys(login, ip) {
+		attempts, err := ts.repo.RecordLoginFailure(ctx, key, ts.lockout)
+		if err != nil {
+			return nil, err
+		}
+
+		if attempts.Failures < limits[i] {
+			continue
+		}
+
+		until := time.Now().Add(ts.lockout)
+		if err := ts.repo.LockLogin(ctx, key, until); err != nil {
+			return nil, err
+		}
+
+		lockout, err := ts.lockoutRepo.CreateLockout(ctx, &models.LoginLockout{
+			Key:         key,
+			IP:          ip,
+			Failures:    attempts.Failures,
+			LockedUntil: until,
+		})
+		if err != nil {
+			return nil, err
+		}
+		lockouts = append(lockouts, *lockout)
+	}
+
+	return lockouts, nil
+}
+
+// Success forgets failed attempts of login. Failures of IP are kept,
+// so attacker can not reset them by logging into own account.
+func (ts *ThrottleService) Success(ctx context.Context, login string) error {
+	return ts.repo.ResetLoginAttempts(ctx, loginThrottleKey(login))
+}
+
+// throttleKeys returns keys counting attempts of login and IP
+func throttleKeys(login string, ip string) []string {
+	return []string{loginThrottleKey(login), "ip:" + ip}
+}
+
+// loginThrottleKey returns key counting attempts of login
+func loginThrottleKey(login string) string {
+	return "login:" + login
+}
+
+// loginDelay returns delay of attempt after given number of failures
+func loginDelay(failures int) time.Duration {
+	delay := loginDelayBase
+	for i := 1; i < failures && delay < loginDelayMax; i++ {
+		delay *= 2
+	}
+	return min(delay, loginDelayMax)
+}
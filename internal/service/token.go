@@ -3,6 +3,6 @@ package service
 import "github.com/rookgm/gophermart/internal/models"
 
 type TokenService interface {
-	CreateToken(user *models.User) (string, error)
+	CreateToken(user *models.User) (string, *models.TokenPayload, error)
 	VerifyToken(tokenString string) (*models.TokenPayload, error)
 }
@@ -0,0 +1,27 @@
+package service
+
+import "context"
+
+// TxRepositories is set of repositories bound to single transaction
+type TxRepositories interface {
+	// Users returns user repository
+	Users() UserRepository
+	// Orders returns order repository
+	Orders() OrderRepository
+	// Withdrawals returns withdrawal repository
+	Withdrawals() WithdrawalRepository
+	// Ledger returns ledger repository
+	Ledger() LedgerRepository
+	// BonusRules returns bonus rule repository
+	BonusRules() BonusRuleRepository
+	// Tokens returns token repository
+	Tokens() TokenRepository
+	// PasswordResets returns password reset repository
+	PasswordResets() PasswordResetRepository
+}
+
+// UnitOfWork is interface for running repository calls atomically
+type UnitOfWork interface {
+	// WithTx runs fn with repositories bound to single transaction
+	WithTx(ctx context.Context, fn func(repos TxRepositories) error) error
+}
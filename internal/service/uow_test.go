@@ -0,0 +1,33 @@
+package service
+
+import "context"
+
+// fakeTxRepositories is transaction repositories backed by fakes. Repositories
+// not set by test panic when used.
+type fakeTxRepositories struct {
+	TxRepositories
+	users  UserRepository
+	ledger LedgerRepository
+	tokens TokenRepository
+}
+
+func (fr fakeTxRepositories) Users() UserRepository {
+	return fr.users
+}
+
+func (fr fakeTxRepositories) Ledger() LedgerRepository {
+	return fr.ledger
+}
+
+func (fr fakeTxRepositories) Tokens() TokenRepository {
+	return fr.tokens
+}
+
+// fakeUnitOfWork runs fn with the same repositories, changes are not rolled back
+type fakeUnitOfWork struct {
+	repos fakeTxRepositories
+}
+
+func (fu fakeUnitOfWork) WithTx(_ context.Context, fn func(repos TxRepositories) error) error {
+	return fn(fu.repos)
+}
@@ -3,48 +3,97 @@ package service
 import (
 	"context"
 	"github.com/rookgm/gophermart/internal/models"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // UserRepository is interface for interacting with user-related data
 type UserRepository interface {
 	// CreateUser insert new user into database
 	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
-	// GetUserByLogin retrieves user info by login
-	GetUserByLogin(ctx context.Context, login string) (*models.User, error)
+	// GetUserByLogin retrieves user info of tenant by login
+	GetUserByLogin(ctx context.Context, tenantID uint64, login string) (*models.User, error)
+	// GetUserByID retrieves user info by id
+	GetUserByID(ctx context.Context, id uint64) (*models.User, error)
+	// SearchUsersByLogin returns users of tenant which login starts with prefix
+	SearchUsersByLogin(ctx context.Context, tenantID uint64, prefix string, limit int) ([]models.User, error)
+	// UpdatePassword replaces password hash of user
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	// ReplacePassword replaces password hash of user if it has not been changed since it was read
+	ReplacePassword(ctx context.Context, id uint64, oldPassword string, newPassword string) error
+	// LockUserByID locks user until the end of transaction
+	LockUserByID(ctx context.Context, id uint64) error
+}
+
+// SignupBonusService is interface for crediting signup bonuses
+type SignupBonusService interface {
+	// ApplySignupBonuses credits signup bonuses to registered user
+	ApplySignupBonuses(ctx context.Context, repos TxRepositories, user *models.User) error
+}
+
+// userSearchLimit is maximum number of users returned by search
+const userSearchLimit = 50
+
+// CredentialPolicy is interface for validating user credentials
+type CredentialPolicy interface {
+	// Validate validates login and password of new user
+	Validate(login string, password string) error
 }
 
 // UserService implements UserService interface
 type UserService struct {
 	repo     UserRepository
 	tokenSvc TokenService
+	uow      UnitOfWork
+	bonusSvc SignupBonusService
+	hasher   PasswordHasher
+	policy   CredentialPolicy
+	audit    AuditRecorder
 }
 
 // NewUserService creates new UserService instance
-func NewUserService(repo UserRepository, ts TokenService) *UserService {
-	return &UserService{repo: repo, tokenSvc: ts}
+func NewUserService(repo UserRepository, ts TokenService, uow UnitOfWork, bonusSvc SignupBonusService, hasher PasswordHasher, policy CredentialPolicy, audit AuditRecorder) *UserService {
+	return &UserService{repo: repo, tokenSvc: ts, uow: uow, bonusSvc: bonusSvc, hasher: hasher, policy: policy, audit: audit}
 }
 
-// Register is registers new user
+// Register is registers new user of user.TenantID and credits signup bonuses.
+// Returns ValidationError if credentials violate policy.
 func (us *UserService) Register(ctx context.Context, user *models.User) (*models.User, error) {
-	hashedPassword, err := HashPassword(user.Password)
+	if err := us.policy.Validate(user.Login, user.Password); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := us.hasher.Hash(user.Password)
 	if err != nil {
 		return nil, err
 	}
 
 	user.Password = hashedPassword
+	user.Role = models.RoleUser
 
-	user, err = us.repo.CreateUser(ctx, user)
+	err = us.uow.WithTx(ctx, func(repos TxRepositories) error {
+		user, err = repos.Users().CreateUser(ctx, user)
+		if err != nil {
+			return err
+		}
 
-	return user, err
-}
-
-// HashPassword returns bcrypt hash of password
-func HashPassword(password string) (string, error) {
-	hashPwd, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		return us.bonusSvc.ApplySignupBonuses(ctx, repos, user)
+	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(hashPwd), nil
+	us.audit.Record(ctx, auditEvent(models.AuditUserRegistered, user.TenantID, user.ID, user.ID, map[string]interface{}{
+		"login": user.Login,
+	}))
+
+	return user, nil
+}
+
+// GetUser returns user by id
+func (us *UserService) GetUser(ctx context.Context, userID uint64) (*models.User, error) {
+	return us.repo.GetUserByID(ctx, userID)
+}
+
+// SearchUsers returns users of tenant which login starts with prefix
+func (us *UserService) SearchUsers(ctx context.Context, tenantID uint64, prefix string) ([]models.User, error) {
+	return us.repo.SearchUsersByLogin(ctx, tenantID, prefix, userSearchLimit)
 }
@@ -0,0 +1,236 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"github.com/rookgm/gophermart/internal/accrual"
+	"github.com/rookgm/gophermart/internal/models"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	// pendingOrdersLimit is max number of orders fetched per poll
+	pendingOrdersLimit = 100
+	// maxCheckBackoff is max delay between accrual checks of order which does not change
+	maxCheckBackoff = 10 * time.Minute
+	// maxNotRegisteredAttempts is number of consecutive checks after which order unknown to accrual system is invalidated
+	maxNotRegisteredAttempts = 20
+)
+
+// OrderRepository is interface for interacting with order-related data
+type OrderRepository interface {
+	// GetOrdersDueForCheck returns orders having one of statuses which are due for accrual check
+	GetOrdersDueForCheck(ctx context.Context, statuses []string, limit int) ([]models.Order, error)
+	// ScheduleOrderCheck counts accrual check which did not change order and postpones the next one by delay.
+	// notRegistered tells whether accrual system did not know order.
+	ScheduleOrderCheck(ctx context.Context, id uint64, delay time.Duration, notRegistered bool) error
+}
+
+// AccrualService is interface for applying accrual system results
+type AccrualService interface {
+	// ApplyAccrual updates order status and credits accrual of processed order
+	ApplyAccrual(ctx context.Context, orderID uint64, status string, accrual *float64) error
+}
+
+// TenantService is interface for listing tenants
+type TenantService interface {
+	// ListTenants returns all tenants
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+}
+
+// AccrualClient is interface for interacting with accrual systems of tenants
+type AccrualClient interface {
+	// SetTenants updates accrual system addresses of tenants
+	SetTenants(tenants []models.Tenant)
+	// GetOrderAccrual returns order accrual calculated by accrual system of tenant
+	GetOrderAccrual(ctx context.Context, tenantID uint64, number string) (*models.Accrual, error)
+}
+
+// AccrualWorker polls accrual systems and updates status of unprocessed orders
+type AccrualWorker struct {
+	repo      OrderRepository
+	svc       AccrualService
+	tenantSvc TenantService
+	client    AccrualClient
+	logger    *zap.Logger
+	workers   int
+	interval  time.Duration
+}
+
+// NewAccrualWorker creates new AccrualWorker instance
+func NewAccrualWorker(repo OrderRepository, svc AccrualService, tenantSvc TenantService, client AccrualClient, logger *zap.Logger, workers int, interval time.Duration) *AccrualWorker {
+	if workers < 1 {
+		workers = 1
+	}
+	return &AccrualWorker{
+		repo:      repo,
+		svc:       svc,
+		tenantSvc: tenantSvc,
+		client:    client,
+		logger:    logger,
+		workers:   workers,
+		interval:  interval,
+	}
+}
+
+// Run polls unprocessed orders until context is done
+func (aw *AccrualWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(aw.interval)
+	defer ticker.Stop()
+
+	for {
+		aw.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches unprocessed orders and processes them by worker pool
+func (aw *AccrualWorker) poll(ctx context.Context) {
+	// accrual system addresses of tenants may have changed, previous ones are used if tenants can not be read
+	tenants, err := aw.tenantSvc.ListTenants(ctx)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			aw.logger.Error("Error getting tenants", zap.Error(err))
+		}
+	} else {
+		aw.client.SetTenants(tenants)
+	}
+
+	orders, err := aw.repo.GetOrdersDueForCheck(ctx, []string{models.OrderStatusNew, models.OrderStatusProcessing}, pendingOrdersLimit)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			aw.logger.Error("Error getting unprocessed orders", zap.Error(err))
+		}
+		return
+	}
+
+	jobs := make(chan models.Order)
+
+	var wg sync.WaitGroup
+	for i := 0; i < aw.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for order := range jobs {
+				aw.process(ctx, order)
+			}
+		}()
+	}
+
+dispatch:
+	for _, order := range orders {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- order:
+		}
+	}
+	close(jobs)
+
+	wg.Wait()
+}
+
+// process requests order accrual and updates order if status has changed.
+// Otherwise the next check is postponed, so orders which do not change do not hold up the queue.
+func (aw *AccrualWorker) process(ctx context.Context, order models.Order) {
+	acc, err := aw.client.GetOrderAccrual(ctx, order.TenantID, order.Number)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+
+		// failed request tells nothing about order, only consecutive answers that order
+		// is unknown lead to invalidation
+		notRegistered := errors.Is(err, accrual.ErrOrderNotRegistered)
+		if !notRegistered {
+			aw.logger.Error("Error getting order accrual", zap.String("number", order.Number), zap.Error(err))
+		} else if order.NotRegistered+1 >= maxNotRegisteredAttempts {
+			aw.invalidate(ctx, order)
+			return
+		}
+
+		aw.postpone(ctx, order, notRegistered)
+		return
+	}
+
+	status, ok := orderStatus(acc.Status)
+	if !ok {
+		aw.logger.Warn("Unknown accrual status", zap.String("number", order.Number), zap.String("status", acc.Status))
+		aw.postpone(ctx, order, false)
+		return
+	}
+
+	if status == order.Status {
+		aw.postpone(ctx, order, false)
+		return
+	}
+
+	// accrual is persisted only when calculation is finished
+	var amount *float64
+	if status == models.OrderStatusProcessed {
+		amount = acc.Accrual
+	}
+
+	if err := aw.svc.ApplyAccrual(ctx, order.ID, status, amount); err != nil {
+		aw.logger.Error("Error updating order status", zap.String("number", order.Number), zap.Error(err))
+		return
+	}
+
+	aw.logger.Debug("Order status updated",
+		zap.String("number", order.Number),
+		zap.String("status", status),
+	)
+}
+
+// postpone schedules the next accrual check of order with exponential backoff
+func (aw *AccrualWorker) postpone(ctx context.Context, order models.Order, notRegistered bool) {
+	err := aw.repo.ScheduleOrderCheck(ctx, order.ID, checkBackoff(aw.interval, order.Attempts), notRegistered)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		aw.logger.Error("Error scheduling order check", zap.String("number", order.Number), zap.Error(err))
+	}
+}
+
+// invalidate moves order which accrual system has never registered out of the queue.
+// Order can be returned to the queue by back-office.
+func (aw *AccrualWorker) invalidate(ctx context.Context, order models.Order) {
+	if err := aw.svc.ApplyAccrual(ctx, order.ID, models.OrderStatusInvalid, nil); err != nil {
+		aw.logger.Error("Error invalidating order", zap.String("number", order.Number), zap.Error(err))
+		return
+	}
+
+	aw.logger.Warn("Order is not registered in accrual system, invalidated",
+		zap.String("number", order.Number),
+		zap.Int("attempts", order.NotRegistered+1),
+	)
+}
+
+// checkBackoff returns delay of the next check of order checked attempts times before,
+// it doubles with each attempt up to maxCheckBackoff
+func checkBackoff(interval time.Duration, attempts int) time.Duration {
+	backoff := interval
+	for i := 0; i < attempts && backoff < maxCheckBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxCheckBackoff)
+}
+
+// orderStatus maps accrual system status to order status
+func orderStatus(accrualStatus string) (string, bool) {
+	switch accrualStatus {
+	case models.AccrualStatusRegistered, models.AccrualStatusProcessing:
+		return models.OrderStatusProcessing, true
+	case models.AccrualStatusInvalid:
+		return models.OrderStatusInvalid, true
+	case models.AccrualStatusProcessed:
+		return models.OrderStatusProcessed, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"github.com/rookgm/gophermart/internal/accrual"
+	"github.com/rookgm/gophermart/internal/models"
+	"go.uber.org/zap"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first check", interval: time.Second, attempts: 0, want: time.Second},
+		{name: "doubles with each attempt", interval: time.Second, attempts: 3, want: 8 * time.Second},
+		{name: "capped", interval: time.Second, attempts: 10, want: maxCheckBackoff},
+		{name: "many attempts do not overflow", interval: time.Second, attempts: 1000, want: maxCheckBackoff},
+		{name: "interval above cap", interval: time.Hour, attempts: 0, want: maxCheckBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkBackoff(tt.interval, tt.attempts); got != tt.want {
+				t.Errorf("checkBackoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeOrderRepository keeps check counters of single order
+type fakeOrderRepository struct {
+	OrderRepository
+	order models.Order
+}
+
+func (fr *fakeOrderRepository) ScheduleOrderCheck(_ context.Context, _ uint64, _ time.Duration, notRegistered bool) error {
+	fr.order.Attempts++
+	if notRegistered {
+		fr.order.NotRegistered++
+	} else {
+		fr.order.NotRegistered = 0
+	}
+	return nil
+}
+
+// fakeAccrualService keeps applied statuses
+type fakeAccrualService struct {
+	statuses []string
+}
+
+func (fs *fakeAccrualService) ApplyAccrual(_ context.Context, _ uint64, status string, _ *float64) error {
+	fs.statuses = append(fs.statuses, status)
+	return nil
+}
+
+// fakeAccrualClient answers with errors in order
+type fakeAccrualClient struct {
+	AccrualClient
+	errs []error
+}
+
+func (fc *fakeAccrualClient) GetOrderAccrual(context.Context, uint64, string) (*models.Accrual, error) {
+	err := fc.errs[0]
+	fc.errs = fc.errs[1:]
+	return nil, err
+}
+
+// repeatErr returns err repeated n times
+func repeatErr(err error, n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+func TestAccrualWorkerInvalidatesNotRegisteredOrder(t *testing.T) {
+	errUnavailable := errors.New("accrual system unavailable")
+
+	tests := []struct {
+		name        string
+		errs        []error
+		wantInvalid bool
+	}{
+		{
+			name:        "consecutive not registered answers invalidate order",
+			errs:        repeatErr(accrual.ErrOrderNotRegistered, maxNotRegisteredAttempts),
+			wantInvalid: true,
+		},
+		{
+			name: "errors then not registered answer do not invalidate order",
+			errs: append(repeatErr(errUnavailable, maxNotRegisteredAttempts*2), accrual.ErrOrderNotRegistered),
+		},
+		{
+			name: "error resets not registered answers",
+			errs: append(append(repeatErr(accrual.ErrOrderNotRegistered, maxNotRegisteredAttempts-1), errUnavailable),
+				repeatErr(accrual.ErrOrderNotRegistered, maxNotRegisteredAttempts-1)...),
+		},
+		{
+			name: "not registered answers after errors invalidate order",
+			errs: append(repeatErr(errUnavailable, maxNotRegisteredAttempts),
+				repeatErr(accrual.ErrOrderNotRegistered, maxNotRegisteredAttempts)...),
+			wantInvalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOrderRepository{order: models.Order{ID: 1, Number: "12345678903", Status: models.OrderStatusNew}}
+			svc := &fakeAccrualService{}
+			client := &fakeAccrualClient{errs: tt.errs}
+			aw := NewAccrualWorker(repo, svc, nil, client, zap.NewNop(), 1, time.Second)
+
+			for range tt.errs {
+				aw.process(context.Background(), repo.order)
+			}
+
+			wantStatuses := []string(nil)
+			if tt.wantInvalid {
+				wantStatuses = []string{models.OrderStatusInvalid}
+			}
+			if !reflect.DeepEqual(svc.statuses, wantStatuses) {
+				t.Errorf("process() applied statuses %v, want %v", svc.statuses, wantStatuses)
+			}
+		})
+	}
+}
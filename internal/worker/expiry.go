@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ExpiryService is interface for expiring points and tokens
+type ExpiryService interface {
+	// ExpirePoints moves unconsumed points of expired credits from user accounts
+	ExpirePoints(ctx context.Context) error
+	// PruneRevokedTokens deletes expired access tokens from revocation list
+	PruneRevokedTokens(ctx context.Context) error
+}
+
+// ExpiryWorker periodically expires points and prunes revoked tokens
+type ExpiryWorker struct {
+	svc      ExpiryService
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+// NewExpiryWorker creates new ExpiryWorker instance
+func NewExpiryWorker(svc ExpiryService, logger *zap.Logger, interval time.Duration) *ExpiryWorker {
+	return &ExpiryWorker{
+		svc:      svc,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Run expires points and prunes revoked tokens until context is done
+func (ew *ExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(ew.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := ew.svc.ExpirePoints(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			ew.logger.Error("Error expiring points", zap.Error(err))
+		}
+
+		if err := ew.svc.PruneRevokedTokens(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			ew.logger.Error("Error pruning revoked tokens", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}